@@ -6,14 +6,26 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ZB-io/internal/roostcli/pkg/cluster"
 	"github.com/ZB-io/internal/roostcli/pkg/config"
+	"github.com/ZB-io/internal/roostcli/pkg/kubeconfig"
+	"github.com/ZB-io/internal/roostcli/pkg/pricing"
+	"github.com/ZB-io/internal/roostcli/pkg/resolve"
 	"github.com/ZB-io/internal/roostcli/pkg/spinner"
 	"github.com/ZB-io/internal/roostcli/pkg/utils"
 	"github.com/jedib0t/go-pretty/table"
@@ -43,11 +55,17 @@ var clusterCmd = &cobra.Command{
 	},
 	Example: `
 	roost cluster create
+	roost cluster apply -f fleet.yaml
 	roost cluster list
+	roost cluster watch
 	roost cluster ui
 	roost cluster get-details
 	roost cluster get-kubeconfig
+	roost cluster exec ExampleAlias -- kubectl get pods
+	roost cluster shell ExampleAlias
 	roost cluster stop
+	roost cluster start
+	roost cluster extend --by 2h
 	roost cluster delete
 	
 	`,
@@ -56,7 +74,11 @@ var clusterCmd = &cobra.Command{
 var clusterCreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Launch a Roost Cluster.",
-	Long:  `A command to start a Roost cluster, it prompts the user for the cluster specifications, if not provided then default values of the specifications are used.`,
+	Long: `A command to start a Roost cluster, it prompts the user for the cluster specifications, if not provided then default values of the specifications are used.
+The region, instance type, k8s version, AMI, disk size and number of workers are checked against a catalogue before the
+cluster is requested. A catalogue.json next to the config, or the catalogue_file setting, replaces its lists.
+The clusters are also checked against the cluster policy, policy.yaml next to the config and the policy_file setting,
+which may limit the active clusters per email, the worker nodes, the expiry and the instance types.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
 		clusterObj := cluster.CreateClusterRequest{}
@@ -67,12 +89,10 @@ var clusterCreateCmd = &cobra.Command{
 			cmd.Help()
 			return
 		}
-		isSet := cmd.Flags().Lookup("alias").Changed
-		if !isSet {
-			clusterObj.Alias = fmt.Sprintf("roostcli-%d", time.Now().Unix())
-		} else {
-			clusterObj.Alias, _ = cmd.Flags().GetString("alias")
+		if cmd.Flags().Lookup("kubeconfig").Changed && !cmd.Flags().Lookup("wait").Changed {
+			cobra.CheckErr(fmt.Errorf("--kubeconfig requires --wait"))
 		}
+		clusterObj.Alias, _ = cmd.Flags().GetString("alias")
 		clusterObj.Namespace, _ = cmd.Flags().GetString("namespace")
 		clusterObj.Ami, _ = cmd.Flags().GetString("ami")
 		clusterObj.InstanceType, _ = cmd.Flags().GetString("instance-type")
@@ -82,46 +102,597 @@ var clusterCreateCmd = &cobra.Command{
 		clusterObj.K8sVersion, _ = cmd.Flags().GetString("k8s")
 		clusterObj.WorkerNodes, _ = cmd.Flags().GetInt("nodes")
 		clusterObj.Email, _ = cmd.Flags().GetString("email")
+		if name, _ := cmd.Flags().GetString("preset"); name != "" {
+			preset, err := cluster.FindPreset(name)
+			cobra.CheckErr(err)
+			cobra.CheckErr(preset.Validate())
+			clusterObj = applyPreset(cmd, clusterObj, preset)
+		}
+		isSet := clusterObj.Alias != ""
 
-		err = utils.AcceptFromPrompt(&clusterObj)
-		if err != nil {
-			cobra.CheckErr(fmt.Errorf("create cluster prompt error %q", err.Error()))
+		var requests []cluster.CreateClusterRequest
+		files, _ := cmd.Flags().GetStringSlice("file")
+		if len(files) > 0 {
+			// The flags are the defaults of every cluster in the spec files, which have to name their clusters.
+			requests, err = readClusterSpecs(files, clusterObj)
+			cobra.CheckErr(err)
+		} else {
+			if !isSet {
+				clusterObj.Alias = fmt.Sprintf("roostcli-%d", time.Now().Unix())
+			}
+			// Flags which were given are checked before the form, which asks for the others.
+			cobra.CheckErr(validateCreateRequest(cmd, clusterObj, true))
+			err = utils.AcceptFromPrompt(&clusterObj)
+			if err != nil {
+				cobra.CheckErr(fmt.Errorf("create cluster prompt error %q", err.Error()))
+			}
+			cobra.CheckErr(validateCreateRequest(cmd, clusterObj, false))
+			requests = append(requests, clusterObj)
+		}
+
+		launchClusters(cmd, requests)
+	},
+	Example: `
+	roost cluster create
+	roost cluster create --email test@mail.com
+	roost cluster create --alias example.Alias
+	roost cluster create --alias example.Alias --wait --timeout 20m --kubeconfig
+	roost cluster create -f clusters.yaml
+	BUILD_ID=42 roost cluster create -f ci.yaml --email ci@ourco.com --wait
+	cat clusters.json | roost cluster create -f -
+	roost cluster create -f clusters.yaml --dry-run
+	roost cluster create --preset ci --alias ci-42
+	roost cluster create --preset ci --nodes 5
+	roost cluster create --preset ./presets/ci.yaml
+	`,
+}
+
+/*
+launchClusters requests the clusters after checking the policy and printing their estimated cost, or only prints the
+requests with --dry-run.
+With --wait it waits for them, optionally downloading their kubeconfigs, and it exits non-zero when one fails.
+// It is shared by the commands creating clusters, which register the dry-run, wait, timeout, kubeconfig and
+// override-policy flags.
+*/
+func launchClusters(cmd *cobra.Command, requests []cluster.CreateClusterRequest) {
+	enforcePolicy(cmd, requests, nil)
+	printCostEstimates(requests)
+	authToken := viper.Get("roost_auth_token").(string)
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		for _, request := range requests {
+			request.RoostAuthToken = authToken
+			utils.PrintDryRun(http.MethodPost, cluster.LaunchEndpoint, request)
 		}
+		return
+	}
 
-		clusterObj.RoostAuthToken = viper.Get("roost_auth_token").(string)
+	var launched []string
+	for _, request := range requests {
+		request.RoostAuthToken = authToken
 		spinner := spinner.NewSpinner()
-		spinner.Start("Creating a cluster")
-		apiEndPoint := "/api/application/client/launchCluster"
-		reqBuff, err := json.Marshal(clusterObj)
+		spinner.Start("Creating cluster " + request.Alias)
+		if err := cluster.Launch(request); err != nil {
+			spinner.Stop(false)
+			fmt.Println("Unable to create cluster: ", err.Error())
+			continue
+		}
+		spinner.Stop(true)
+		launched = append(launched, request.Alias)
+	}
+	failed := len(launched) < len(requests)
+
+	wait, _ := cmd.Flags().GetBool("wait")
+	if !wait {
+		if len(launched) > 0 {
+			fmt.Println("cluster creation in progress, It may take 5 min to comeup.\nRequested Cluster alias: ", strings.Join(launched, ", "))
+		}
+	} else {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		getKubeconfig, _ := cmd.Flags().GetBool("kubeconfig")
+		deadline := time.Now().Add(timeout)
+		for _, alias := range launched {
+			if !waitForCluster(authToken, alias, time.Until(deadline), getKubeconfig) {
+				failed = true
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+/*
+enforcePolicy checks the requests against the cluster policy and the active clusters, and exits with the violations
+unless --override-policy is given. Overrides are logged next to the config, except on dry runs which launch nothing.
+// current is fetched when nil and the policy needs it.
+*/
+func enforcePolicy(cmd *cobra.Command, requests []cluster.CreateClusterRequest, current []cluster.ClusterList) {
+	policy, err := cluster.LoadPolicy()
+	cobra.CheckErr(err)
+	if policy.IsEmpty() {
+		return
+	}
+	if current == nil && policy.MaxActiveClusters > 0 {
+		clusterListData, err := cluster.FetchClusterList(viper.Get("roost_auth_token").(string))
 		cobra.CheckErr(err)
+		current = clusterListData.Clusters
+	}
+	violations := policy.Check(requests, current)
+	if len(violations) == 0 {
+		return
+	}
+	sources := strings.Join(policy.Sources, ", ")
+	list := strings.Join(violations, "\n  ")
+	if override, _ := cmd.Flags().GetBool("override-policy"); !override {
+		cobra.CheckErr(fmt.Errorf("the cluster policy of %s is violated:\n  %s\nAsk for the limits to be raised, or use --override-policy if this is justified. Overrides are logged", sources, list))
+	}
+	fmt.Printf("Overriding the cluster policy of %s:\n  %s\n", sources, list)
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return
+	}
+	var aliases []string
+	for _, request := range requests {
+		aliases = append(aliases, request.Alias)
+	}
+	path, err := cluster.RecordPolicyOverride(cluster.PolicyOverride{Time: time.Now(), Command: cmd.CommandPath(), Clusters: aliases, Violations: violations})
+	if err != nil {
+		// The override is only allowed when it can be accounted for.
+		cobra.CheckErr(fmt.Errorf("unable to log the policy override: %s", err.Error()))
+	}
+	fmt.Println("The override is logged in", path)
+}
+
+/*
+printCostEstimates prints what the requested clusters are expected to cost from the pricing table. The estimate is
+only a guide, so a missing price is reported without stopping the create.
+*/
+func printCostEstimates(requests []cluster.CreateClusterRequest) {
+	prices, _, err := pricing.Load()
+	if err != nil {
+		fmt.Println("Unable to estimate the cost:", err.Error())
+		return
+	}
+	total := 0.0
+	for _, request := range requests {
+		rates, err := cluster.RequestRates(request, prices)
+		if err != nil {
+			fmt.Printf("Unable to estimate the cost of cluster %s: %s\n", request.Alias, err.Error())
+			continue
+		}
+		cost := rates.Hourly() * float64(request.ClusterExpiry)
+		total += cost
+		fmt.Printf("Estimated cost of cluster %s: %s/hour, %s for %dh (%d x %s in %s, %dGB disk each)\n", request.Alias,
+			prices.Format(rates.Hourly()), prices.Format(cost), request.ClusterExpiry, rates.Nodes, rates.InstanceType, rates.Region, rates.DiskGB)
+	}
+	if len(requests) > 1 {
+		fmt.Printf("Estimated cost of all clusters: %s\n", prices.Format(total))
+	}
+}
 
-		var clusterResp cluster.ClusterApiResponse
-		body := bytes.NewReader(reqBuff)
-		status, response, err := utils.HTTPClientRequest(http.MethodPost, apiEndPoint, "", body)
+var clusterCostCmd = &cobra.Command{
+	Use:   "cost",
+	Short: "Report what Roost clusters have cost",
+	Long: `A command to add up what the listed roost clusters have cost so far, from how long they ran and the pricing table,
+grouped by email, type, cluster and period. The instance type, region and disk size are known for clusters created from
+this machine; the defaults of the pricing table are assumed for the others. The cluster list only tells when a cluster
+last started and stopped, so the cost of clusters which were restarted is a lower bound.
+The bundled prices can be updated with a pricing file, pricing.json next to the config or the pricing_file setting,
+which only needs the prices it changes. --print-pricing prints the prices in use as a starting point.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		prices, source, err := pricing.Load()
 		cobra.CheckErr(err)
-		if status != http.StatusCreated {
-			json.Unmarshal(response, &clusterResp)
-			spinner.Stop(false)
-			fmt.Println("Unable to create cluster: ", clusterResp.ClusterRespMessage)
+		if printPricing, _ := cmd.Flags().GetBool("print-pricing"); printPricing {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			cobra.CheckErr(encoder.Encode(prices))
+			return
+		}
+		output, _ := cmd.Flags().GetString("output")
+		if output != "table" && output != "json" {
+			cobra.CheckErr(fmt.Errorf("unknown output format %q, use table or json", output))
+		}
+		groupBy, _ := cmd.Flags().GetStringSlice("by")
+		period, _ := cmd.Flags().GetString("period")
+		now := time.Now()
+		var from time.Time
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			from, err = utils.ParseUserTime(since, now)
+			cobra.CheckErr(err)
+		}
+
+		clusterListData, err := cluster.FetchClusterList(viper.Get("roost_auth_token").(string))
+		cobra.CheckErr(err)
+		clusters, err := selectClusters(cmd, clusterListData.Clusters)
+		cobra.CheckErr(err)
+		records, _ := cluster.LoadRecords()
+		var usages []cluster.Usage
+		assumed, unknown := 0, 0
+		earliest := now
+		for _, clusterData := range clusters {
+			usage, err := cluster.ClusterUsage(clusterData, records, prices, now)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Skipping", err.Error())
+				continue
+			}
+			if usage.Created.IsZero() && usage.Running.IsZero() {
+				unknown++
+				continue
+			}
+			if usage.Assumed {
+				assumed++
+			}
+			for _, t := range []time.Time{usage.Created, usage.Running} {
+				if !t.IsZero() && t.Before(earliest) {
+					earliest = t
+				}
+			}
+			usages = append(usages, usage)
+		}
+		if from.IsZero() {
+			from = earliest
+		}
+		report, err := cluster.CostReport(usages, groupBy, period, from, now)
+		cobra.CheckErr(err)
+
+		if output == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if report == nil {
+				report = []cluster.CostLine{}
+			}
+			cobra.CheckErr(encoder.Encode(report))
+			return
+		}
+		if len(report) == 0 {
+			fmt.Println("No cluster costs found")
+			return
+		}
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		var header, footer table.Row
+		for _, column := range groupBy {
+			header = append(header, column)
+			footer = append(footer, "")
+		}
+		footer[0] = "Total"
+		t.AppendHeader(append(header, "Clusters", "Node Hours", "Cost"))
+		t.SetStyle(table.StyleDouble)
+		nodeHours, cost := 0.0, 0.0
+		for _, line := range report {
+			row := table.Row{}
+			for _, value := range line.Group {
+				if value == "" {
+					value = "-"
+				}
+				row = append(row, value)
+			}
+			t.AppendRow(append(row, line.Clusters, fmt.Sprintf("%.1f", line.NodeHours), prices.Format(line.Cost)))
+			nodeHours += line.NodeHours
+			cost += line.Cost
+		}
+		t.AppendFooter(append(footer, "", fmt.Sprintf("%.1f", nodeHours), prices.Format(cost)))
+		fmt.Print("\n")
+		t.Render()
+		fmt.Print("\n")
+
+		if source == "" {
+			source = "bundled"
+		}
+		fmt.Printf("Costs since %s, from the %s prices of %s.\n", from.Local().Format("2006-01-02 15:04 MST"), source, prices.Updated)
+		if assumed > 0 {
+			fmt.Printf("Clusters not created from this machine (%d) are assumed to use %s nodes in %s with 50GB disks.\n", assumed, prices.DefaultInstanceType, prices.DefaultRegion)
+		}
+		if unknown > 0 {
+			fmt.Printf("Clusters whose run times aren't listed (%d) are left out.\n", unknown)
+		}
+	},
+	Example: `
+	roost cluster cost
+	roost cluster cost --by type,period --period week
+	roost cluster cost --by email,cluster --since 30d
+	roost cluster cost --selector email=ci@ourco.com -o json
+	roost cluster cost --print-pricing > ~/.roost/pricing.json
+	`,
+}
+
+// clusterPollInterval is how often commands waiting on a cluster poll the cluster list.
+const clusterPollInterval = 10 * time.Second
+
+// createFlags maps the fields of CreateClusterRequest to the flags of create setting them.
+var createFlags = map[string]string{
+	"Alias":         "alias",
+	"Email":         "email",
+	"Namespace":     "namespace",
+	"Ami":           "ami",
+	"InstanceType":  "instance-type",
+	"DiskSize":      "disk-size",
+	"Region":        "region",
+	"ClusterExpiry": "expiry",
+	"K8sVersion":    "k8s",
+	"WorkerNodes":   "nodes",
+}
+
+// Defaults of the create flags, shared by the commands building a CreateClusterRequest.
+const (
+	defaultNamespace    = "roostcli"
+	defaultAmi          = "ubuntu jammy jellyfish 22.04"
+	defaultInstanceType = "t3.small"
+	defaultDiskSize     = "50GB"
+	defaultRegion       = "ap-south-1"
+	defaultExpiry       = 1
+	defaultK8sVersion   = "1.22.2"
+	defaultWorkerNodes  = 1
+)
+
+/*
+addCreateFlags adds the flags setting the fields of a CreateClusterRequest but the alias, with help texts starting with
+helpPrefix, such as "Default" or "The clone's".
+*/
+func addCreateFlags(cmd *cobra.Command, helpPrefix string) {
+	cmd.Flags().String("email", "", helpPrefix+" customer email")
+	cmd.Flags().StringP("namespace", "n", defaultNamespace, helpPrefix+" namespace")
+	cmd.Flags().String("ami", defaultAmi, helpPrefix+" AMI")
+	cmd.Flags().String("instance-type", defaultInstanceType, helpPrefix+" instance type")
+	cmd.Flags().String("disk-size", defaultDiskSize, helpPrefix+" disk size, at least "+defaultDiskSize)
+	cmd.Flags().String("region", defaultRegion, helpPrefix+" AWS region")
+	cmd.Flags().Int("expiry", defaultExpiry, helpPrefix+" expiry in hours")
+	cmd.Flags().String("k8s", defaultK8sVersion, helpPrefix+" k8s version")
+	cmd.Flags().Int("nodes", defaultWorkerNodes, helpPrefix+" number of worker nodes")
+}
+
+/*
+validateCreateRequest checks the request against its tags and the catalogue, and reports every problem together under
+the flags setting the fields.
+// With onlySet, fields whose flags weren't given are left to the form.
+*/
+func validateCreateRequest(cmd *cobra.Command, request cluster.CreateClusterRequest, onlySet bool) error {
+	if _, err := cluster.LoadCatalogue(); err != nil {
+		return err
+	}
+	failures, err := utils.Validate(&request)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for _, failure := range failures {
+		flag, ok := createFlags[failure.Field.Name]
+		if !ok {
+			continue
+		}
+		if onlySet && !cmd.Flags().Lookup(flag).Changed {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("--%s: %s", flag, failure.Message))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid cluster parameters:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// readClusterSpecs parses the spec files, "-" being stdin, and reports the problems of all of them together.
+func readClusterSpecs(files []string, defaults cluster.CreateClusterRequest) ([]cluster.CreateClusterRequest, error) {
+	if _, err := cluster.LoadCatalogue(); err != nil {
+		return nil, err
+	}
+	var requests []cluster.CreateClusterRequest
+	var errs cluster.SpecErrors
+	for _, file := range files {
+		var specs []cluster.CreateClusterRequest
+		var err error
+		if file == "-" {
+			specs, err = cluster.ParseSpecs("stdin", os.Stdin, defaults)
 		} else {
-			spinner.Stop(true)
-			fmt.Println("cluster creation in progress, It may take 5 min to comeup.\nRequested Cluster alias: ", clusterObj.Alias)
+			f, openErr := os.Open(file)
+			if openErr != nil {
+				return nil, openErr
+			}
+			specs, err = cluster.ParseSpecs(file, f, defaults)
+			f.Close()
+		}
+		var specErrs cluster.SpecErrors
+		if errors.As(err, &specErrs) {
+			errs = append(errs, specErrs...)
+		} else if err != nil {
+			return nil, err
+		}
+		requests = append(requests, specs...)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid cluster spec:\n%s", errs.Error())
+	}
+	return requests, cluster.CheckDuplicateAliases(requests)
+}
+
+// waitForCluster waits for a newly created cluster, printing its phases, and optionally downloads its kubeconfig.
+// It reports whether the cluster became ready.
+func waitForCluster(authToken, alias string, timeout time.Duration, getKubeconfig bool) bool {
+	fmt.Printf("Waiting up to %s for cluster %s to become ready\n", timeout.Truncate(time.Second), alias)
+	started := time.Now()
+	clusterData, err := cluster.Wait(authToken, alias, timeout, clusterPollInterval, func(phase string) {
+		fmt.Printf("[%s] %s: %s\n", time.Since(started).Truncate(time.Second), alias, phase)
+	})
+	if err != nil {
+		fmt.Println("Error:", err.Error())
+		var failed *cluster.FailedError
+		if errors.As(err, &failed) && failed.Cluster.FailureDetails != "" {
+			fmt.Println("Details:", failed.Cluster.FailureDetails)
+		}
+		return false
+	}
+	fmt.Printf("Cluster %s is ready (public IP %s) after %s\n", alias, clusterData.PublicIP, time.Since(started).Truncate(time.Second))
+
+	if getKubeconfig {
+		kubeConfigPath, err := cluster.SaveKubeconfig(authToken, alias)
+		if err != nil {
+			fmt.Println("Error:", err.Error())
+			return false
+		}
+		fmt.Printf("The kubeconfig file is present in %s.\nUse 'export KUBECONFIG=%s'.\n", kubeConfigPath, kubeConfigPath)
+	}
+	return true
+}
+
+var clusterApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Create the clusters declared in spec files which don't exist yet",
+	Long: `A command to keep a fleet of roost clusters in line with spec files, see 'roost cluster create -f' for the format.
+The declared clusters are compared with the existing ones by alias. Missing clusters are created, and clusters which differ from their spec are reported as drift.
+With --prune, clusters applied earlier with the same fleet name which are no longer declared are stopped or deleted. Other clusters are never touched.
+The plan is shown and confirmed before anything is changed. The clusters it creates are checked against the cluster policy, see 'roost cluster create --help'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		files, _ := cmd.Flags().GetStringSlice("file")
+		prune, _ := cmd.Flags().GetString("prune")
+		if prune != "" && prune != cluster.PruneStop && prune != cluster.PruneDelete {
+			cobra.CheckErr(fmt.Errorf("unknown --prune mode %q, use stop or delete", prune))
+		}
+		fleet, _ := cmd.Flags().GetString("fleet")
+		if fleet == "" {
+			if files[0] == "-" {
+				cobra.CheckErr(fmt.Errorf("--fleet is required when reading the spec from stdin"))
+			}
+			fleet = strings.TrimSuffix(filepath.Base(files[0]), filepath.Ext(files[0]))
+		}
+
+		defaults := cluster.CreateClusterRequest{}
+		defaults.Namespace, _ = cmd.Flags().GetString("namespace")
+		defaults.Ami, _ = cmd.Flags().GetString("ami")
+		defaults.InstanceType, _ = cmd.Flags().GetString("instance-type")
+		defaults.DiskSize, _ = cmd.Flags().GetString("disk-size")
+		defaults.Region, _ = cmd.Flags().GetString("region")
+		defaults.ClusterExpiry, _ = cmd.Flags().GetInt("expiry")
+		defaults.K8sVersion, _ = cmd.Flags().GetString("k8s")
+		defaults.WorkerNodes, _ = cmd.Flags().GetInt("nodes")
+		defaults.Email, _ = cmd.Flags().GetString("email")
+		desired, err := readClusterSpecs(files, defaults)
+		cobra.CheckErr(err)
+
+		authToken := viper.Get("roost_auth_token").(string)
+		current, err := cluster.FetchClusterList(authToken)
+		cobra.CheckErr(err)
+		records, err := cluster.LoadRecords()
+		cobra.CheckErr(err)
+		plan := cluster.MakePlan(fleet, desired, current.Clusters, records, prune)
+
+		fmt.Printf("Fleet %s:\n\n%s\n", fleet, plan.String())
+		// The clusters the plan stops or deletes no longer count towards the policy.
+		var creates []cluster.CreateClusterRequest
+		remaining := []cluster.ClusterList{}
+		leaving := map[string]bool{}
+		for _, change := range plan {
+			switch change.Action {
+			case cluster.ActionCreate:
+				creates = append(creates, change.Desired)
+			case cluster.ActionStop, cluster.ActionDelete:
+				leaving[change.Alias] = true
+			}
+		}
+		for _, clusterData := range current.Clusters {
+			if !leaving[clusterData.CustomerToken] {
+				remaining = append(remaining, clusterData)
+			}
+		}
+		enforcePolicy(cmd, creates, remaining)
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if dryRun || !plan.HasChanges() {
+			if !dryRun {
+				cobra.CheckErr(cluster.AdoptIntoFleet(declaredAliases(plan), fleet))
+			}
+			return
+		}
+		yes, _ := cmd.Flags().GetBool("yes")
+		if !yes && !utils.Confirm("Apply this plan?") {
+			fmt.Println("Aborted, nothing was changed")
+			return
+		}
+
+		failed := false
+		var created, deleted []string
+		for _, change := range plan {
+			var err error
+			switch change.Action {
+			case cluster.ActionCreate:
+				change.Desired.RoostAuthToken = authToken
+				if err = cluster.Launch(change.Desired); err == nil {
+					created = append(created, change.Alias)
+				}
+			case cluster.ActionStop:
+				err = cluster.Stop(authToken, change.Alias)
+			case cluster.ActionDelete:
+				if err = cluster.Delete(authToken, change.Alias); err == nil {
+					deleted = append(deleted, change.Alias)
+					if _, removeErr := cluster.RemoveLocalKubeconfig(change.Alias); removeErr != nil {
+						fmt.Println("Unable to remove the kubeconfig of", change.Alias+":", removeErr.Error())
+					}
+				}
+			default:
+				continue
+			}
+			if err != nil {
+				failed = true
+				fmt.Printf("❌ %s %s: %s\n", change.Action, change.Alias, err.Error())
+			} else {
+				fmt.Printf("✔️ %s %s\n", change.Action, change.Alias)
+			}
+		}
+		// Clusters which already existed are adopted too, so they can be pruned once they're dropped from the spec.
+		cobra.CheckErr(cluster.AdoptIntoFleet(declaredAliases(plan), fleet))
+		if len(deleted) > 0 {
+			cobra.CheckErr(cluster.Forget(deleted...))
+		}
+
+		if wait, _ := cmd.Flags().GetBool("wait"); wait {
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			deadline := time.Now().Add(timeout)
+			for _, alias := range created {
+				if !waitForCluster(authToken, alias, time.Until(deadline), false) {
+					failed = true
+				}
+			}
+		}
+		if failed {
+			os.Exit(1)
 		}
 	},
 	Example: `
-	roost cluster create
-	roost cluster create --email test@mail.com
-	roost cluster create --alias example.Alias
+	roost cluster apply -f fleet.yaml
+	roost cluster apply -f fleet.yaml --dry-run
+	roost cluster apply -f fleet.yaml --prune stop
+	roost cluster apply -f fleet.yaml --prune delete --yes --wait
+	cat fleet.yaml | roost cluster apply -f - --fleet team-web
 	`,
 }
 
+// declaredAliases returns the aliases of the clusters in the spec, which exist or are being created.
+func declaredAliases(plan cluster.Plan) []string {
+	var aliases []string
+	for _, change := range plan {
+		if change.Action != cluster.ActionStop && change.Action != cluster.ActionDelete {
+			aliases = append(aliases, change.Alias)
+		}
+	}
+	return aliases
+}
+
 var clusterStopCmd = &cobra.Command{
 	Use:   "stop",
 	Short: "Stop a Roost cluster",
-	Long:  "A command to stop a roost cluster, provides a list of all the currently running/requested clusters. The cluster which needs to be stopped can then be selected from the list or its ID or alias can be provided as flags.",
+	Long:  "A command to stop a roost cluster, provides a list of all the currently running/requested clusters. The clusters which need to be stopped can then be selected from the list (type to filter, space to select several) or their IDs or aliases can be provided as flags.",
 	Run: func(cmd *cobra.Command, args []string) {
-		clusterObj := cluster.ClusterStopObj{}
-
 		if len(args) > 0 {
 			if args[0] != "help" {
 				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
@@ -131,28 +702,16 @@ var clusterStopCmd = &cobra.Command{
 		}
 
 		clusterStop := func(clusterAlias string) {
-			clusterObj.Alias = clusterAlias
-			clusterObj.RoostAuthToken = viper.Get("roost_auth_token").(string)
 			spinner := spinner.NewSpinner()
 			spinner.Start("stopping the requested cluster")
-			apiEndPoint := "/api/application/client/stopLaunchedCluster"
-			reqBuff, err := json.Marshal(clusterObj)
-			cobra.CheckErr(err)
-			body := bytes.NewReader(reqBuff)
-
-			status, resp, err := utils.HTTPClientRequest(http.MethodPost, apiEndPoint, "", body)
-			cobra.CheckErr(err)
-
-			var clusterresp cluster.ClusterApiResponse
-			json.Unmarshal(resp, &clusterresp)
-
-			if status == http.StatusCreated {
-				spinner.Stop(true)
-				fmt.Println("Succesfully stopped the cluster with alias", clusterObj.Alias)
-			} else {
+			err := cluster.Stop(viper.Get("roost_auth_token").(string), clusterAlias)
+			if err != nil {
 				spinner.Stop(false)
-				fmt.Println("Unable to stop cluster:", string(clusterresp.ClusterRespMessage))
+				fmt.Println("Unable to stop cluster:", err.Error())
+				return
 			}
+			spinner.Stop(true)
+			fmt.Println("Succesfully stopped the cluster with alias", clusterAlias)
 		}
 
 		isSetID := cmd.Flags().Lookup("id").Changed
@@ -167,13 +726,28 @@ var clusterStopCmd = &cobra.Command{
 
 		isSetAlias := cmd.Flags().Lookup("alias").Changed
 		if isSetAlias {
-			ClusterAliasArr, _ := cmd.Flags().GetStringSlice("alias")
-			for _, ClusterAlias := range ClusterAliasArr {
+			for _, ClusterAlias := range confirmAliasTargets(cmd, "stop") {
 				clusterStop(ClusterAlias)
 			}
 		}
 
-		if !isSetAlias && !isSetID {
+		isSetSelector := isSelectorSet(cmd)
+		if isSetSelector {
+			clusters, _ := selectClustersForBulk(cmd)
+			if len(clusters) == 0 {
+				return
+			}
+			yes, _ := cmd.Flags().GetBool("yes")
+			if !yes && !utils.Confirm(fmt.Sprintf("Stop these %d clusters?", len(clusters))) {
+				fmt.Println("Aborted, no clusters were stopped")
+				return
+			}
+			for _, clusterData := range clusters {
+				clusterStop(clusterData.CustomerToken)
+			}
+		}
+
+		if !isSetAlias && !isSetID && !isSetSelector {
 			listResponse := cluster.GetClusterList(viper.Get("roost_auth_token").(string))
 			if listResponse.Count < 1 || len(listResponse.Clusters) < 1 {
 				fmt.Println("No running clusters are found")
@@ -191,11 +765,10 @@ var clusterStopCmd = &cobra.Command{
 				return
 			}
 
-			clusterAliasInput := utils.PromptSelectInput(clusterNames, "Select the cluster you want to stop")
-			if clusterAliasInput == "" {
-				return
+			clusterAliasInputs := utils.PromptMultiSelectInput(clusterNames, "Select the clusters you want to stop")
+			for _, clusterAliasInput := range clusterAliasInputs {
+				clusterStop(clusterAliasInput)
 			}
-			clusterStop(clusterAliasInput)
 		}
 	},
 	Example: `
@@ -204,15 +777,172 @@ var clusterStopCmd = &cobra.Command{
 		roost cluster stop --id 1,2,3
 		roost cluster stop --alias ExampleAlias
 		roost cluster stop --alias ExampleAlias1. ExampleAlias2
+		roost cluster stop --alias 'ci-*'
+		roost cluster stop --selector 'status=running,email=ci@ourco.com,age>4h'
 	`,
 }
 
+var clusterStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start a stopped Roost cluster",
+	Long:  `A command to resume stopped roost clusters, provides a list of the stopped clusters. The clusters to start can then be selected from the list (type to filter, space to select several) or their IDs or aliases can be provided as flags.
+Note: the start endpoint, /api/application/client/startLaunchedCluster, is modelled on the stop endpoint and not yet confirmed by the Roost API. Servers without it fail the request.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		clusterAliases := targetClusters(cmd, "start", func(clusterData cluster.ClusterList) bool {
+			return cluster.Status(clusterData) == cluster.StatusStopped
+		})
+
+		authToken := viper.Get("roost_auth_token").(string)
+		failed := false
+		var started []string
+		for _, clusterAlias := range clusterAliases {
+			spinner := spinner.NewSpinner()
+			spinner.Start("starting the requested cluster")
+			if err := cluster.Start(authToken, clusterAlias); err != nil {
+				spinner.Stop(false)
+				fmt.Println("Unable to start cluster:", err.Error())
+				failed = true
+				continue
+			}
+			spinner.Stop(true)
+			fmt.Println("Succesfully started the cluster with alias", clusterAlias)
+			started = append(started, clusterAlias)
+		}
+
+		if wait, _ := cmd.Flags().GetBool("wait"); wait {
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			deadline := time.Now().Add(timeout)
+			for _, clusterAlias := range started {
+				if !waitForCluster(authToken, clusterAlias, time.Until(deadline), false) {
+					failed = true
+				}
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+	Example: `
+	roost cluster start
+	roost cluster start --alias ExampleAlias
+	roost cluster start --id 1,2 --wait
+	roost cluster start --selector 'status=stopped,email=ci@ourco.com'
+	`,
+}
+
+var clusterExtendCmd = &cobra.Command{
+	Use:   "extend",
+	Short: "Push out the expiry of a Roost cluster",
+	Long:  `A command to keep running roost clusters alive for longer. The expiry is extended in whole hours, so --by is rounded up. The clusters can be selected from a list of the running clusters or their IDs or aliases can be provided as flags.
+Note: the extend endpoint, /api/application/client/extendLaunchedCluster, is modelled on the stop endpoint and not yet confirmed by the Roost API. Servers without it fail the request.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		by, _ := cmd.Flags().GetString("by")
+		extendBy, err := utils.ParseDuration(by)
+		cobra.CheckErr(err)
+		if extendBy <= 0 {
+			cobra.CheckErr(fmt.Errorf("--by must be positive"))
+		}
+		hours := int((extendBy + time.Hour - 1) / time.Hour)
+		if time.Duration(hours)*time.Hour != extendBy {
+			fmt.Printf("Expiry is extended in whole hours, extending by %dh\n", hours)
+		}
+
+		clusterAliases := targetClusters(cmd, "extend", func(clusterData cluster.ClusterList) bool {
+			status := cluster.Status(clusterData)
+			return status == cluster.StatusRunning || status == cluster.StatusInProgress
+		})
+
+		authToken := viper.Get("roost_auth_token").(string)
+		failed := false
+		for _, clusterAlias := range clusterAliases {
+			if err := cluster.Extend(authToken, clusterAlias, hours); err != nil {
+				fmt.Println("Unable to extend cluster:", err.Error())
+				failed = true
+				continue
+			}
+			fmt.Printf("Succesfully extended the cluster with alias %s by %dh\n", clusterAlias, hours)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+	Example: `
+	roost cluster extend --alias ExampleAlias --by 2h
+	roost cluster extend --by 1d
+	roost cluster extend --selector 'email=ci@ourco.com' --by 4h --yes
+	`,
+}
+
+/*
+targetClusters returns the aliases of the clusters picked with the --id, --alias or selector flags of cmd,
+or else interactively among the clusters for which eligible is true. Clusters matched by a selector, a glob or
+several aliases are previewed and confirmed unless --yes is set.
+*/
+func targetClusters(cmd *cobra.Command, verb string, eligible func(cluster.ClusterList) bool) []string {
+	var clusterAliases []string
+	if cmd.Flags().Lookup("id").Changed {
+		clusterIDs, _ := cmd.Flags().GetInt32Slice("id")
+		for _, clusterID := range clusterIDs {
+			clusterinfo, err := cluster.GetClusterDetails(int(clusterID), "")
+			cobra.CheckErr(err)
+			clusterAliases = append(clusterAliases, clusterinfo.CustomerToken)
+		}
+		return clusterAliases
+	}
+
+	if cmd.Flags().Lookup("alias").Changed {
+		return confirmAliasTargets(cmd, verb)
+	}
+
+	if isSelectorSet(cmd) {
+		clusters, _ := selectClustersForBulk(cmd)
+		if len(clusters) == 0 {
+			return nil
+		}
+		yes, _ := cmd.Flags().GetBool("yes")
+		if !yes && !utils.Confirm(fmt.Sprintf("%s these %d clusters?", strings.ToUpper(verb[:1])+verb[1:], len(clusters))) {
+			fmt.Println("Aborted, nothing was changed")
+			return nil
+		}
+		for _, clusterData := range clusters {
+			clusterAliases = append(clusterAliases, clusterData.CustomerToken)
+		}
+		return clusterAliases
+	}
+
+	listResponse := cluster.GetClusterList(viper.Get("roost_auth_token").(string))
+	var clusterNames []string
+	for _, clusterData := range listResponse.Clusters {
+		if eligible(clusterData) {
+			clusterNames = append(clusterNames, clusterData.CustomerToken)
+		}
+	}
+	if len(clusterNames) < 1 {
+		fmt.Printf("No clusters to %s are found\n", verb)
+		return nil
+	}
+	return utils.PromptMultiSelectInput(clusterNames, fmt.Sprintf("Select the clusters you want to %s", verb))
+}
+
 var clusterDeleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete a Roost cluster",
-	Long:  `A command to delete a roost cluster, provides a list of currently available clusters. The cluster to be deleted can then be selected from the given list or its ID or alias can be provided as flags.`,
+	Long:  `A command to delete a roost cluster, provides a list of currently available clusters. The clusters to be deleted can then be selected from the given list (type to filter, space to select several) or their IDs or aliases can be provided as flags.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		clusterObj := cluster.ClusterStopObj{}
 		if len(args) > 0 {
 			if args[0] != "help" {
 				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
@@ -221,60 +951,66 @@ var clusterDeleteCmd = &cobra.Command{
 			return
 		}
 
-		clusterDelete := func(clusterAlias string) {
-			clusterObj.Alias = clusterAlias
-			clusterObj.RoostAuthToken = viper.Get("roost_auth_token").(string)
-			spinner := spinner.NewSpinner()
-			spinner.Start("Deleting the requested cluster")
-			apiEndPoint := "/api/application/client/deleteLaunchedCluster"
-			reqBuff, err := json.Marshal(clusterObj)
-			cobra.CheckErr(err)
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+		authToken := viper.Get("roost_auth_token").(string)
 
-			body := bytes.NewReader(reqBuff)
-			status, resp, err := utils.HTTPClientRequest(http.MethodPost, apiEndPoint, "", body)
-			cobra.CheckErr(err)
-			var apiresp cluster.ClusterApiResponse
-			json.Unmarshal(resp, &apiresp)
-
-			home, err := os.UserHomeDir()
-			kubeConfigDir := filepath.Join(home, ".kube", "roostconfig")
-			kubeConfigPath := filepath.Join(kubeConfigDir, clusterAlias)
-			if utils.FileOrFolderExists(kubeConfigPath) {
-				err = os.Remove(kubeConfigPath)
-				if err != nil {
-					spinner.Stop(false)
-					fmt.Println("error deleting the downloaded kubeconfig of the file")
+		clusterDelete := func(clusterAlias string) {
+			if dryRun {
+				utils.PrintDryRun(http.MethodPost, cluster.DeleteEndpoint, cluster.ClusterStopObj{Alias: clusterAlias, RoostAuthToken: authToken})
+				for _, local := range cluster.LocalKubeconfigs(clusterAlias) {
+					fmt.Println("[dry-run] remove", local)
 				}
+				return
 			}
-			if status == http.StatusCreated {
-				spinner.Stop(true)
-				fmt.Println("Succesfully deleted the cluster with Alias", clusterAlias)
-			} else {
+			spinner := spinner.NewSpinner()
+			spinner.Start("Deleting the requested cluster")
+			if err := cluster.Delete(authToken, clusterAlias); err != nil {
 				spinner.Stop(false)
-				fmt.Println("Unable to delete cluster:", string(apiresp.ClusterRespMessage))
+				// The cluster is still there, so its kubeconfig is kept.
+				fmt.Println("Unable to delete cluster:", err.Error())
+				return
+			}
+			spinner.Stop(true)
+			fmt.Println("Succesfully deleted the cluster with Alias", clusterAlias)
+			if _, err := cluster.RemoveLocalKubeconfig(clusterAlias); err != nil {
+				fmt.Println("Unable to remove the kubeconfig of", clusterAlias+":", err.Error())
+			}
+			if err := cluster.Forget(clusterAlias); err != nil {
+				fmt.Println("Unable to forget the record of", clusterAlias+":", err.Error())
 			}
 		}
 
+		var clusterAliases []string
+		var clusterListData cluster.ClusterListResponse
+
 		isSetID := cmd.Flags().Lookup("id").Changed
 		if isSetID {
 			ClusterIDs, _ := cmd.Flags().GetInt32Slice("id")
 			for _, ClusterID := range ClusterIDs {
 				clusterinfo, err := cluster.GetClusterDetails(int(ClusterID), "")
 				cobra.CheckErr(err)
-				clusterDelete(clusterinfo.CustomerToken)
+				clusterAliases = append(clusterAliases, clusterinfo.CustomerToken)
 			}
 		}
 
 		isSetAlias := cmd.Flags().Lookup("alias").Changed
 		if isSetAlias {
 			ClusterAliasArr, _ := cmd.Flags().GetStringSlice("alias")
-			for _, ClusterAlias := range ClusterAliasArr {
-				clusterDelete(ClusterAlias)
+			clusterAliases, clusterListData = resolveClusterAliases(ClusterAliasArr)
+		}
+
+		isSetSelector := isSelectorSet(cmd)
+		if isSetSelector {
+			var clusters []cluster.ClusterList
+			clusters, clusterListData = selectClustersForBulk(cmd)
+			for _, clusterData := range clusters {
+				clusterAliases = append(clusterAliases, clusterData.CustomerToken)
 			}
 		}
 
-		if !isSetAlias && !isSetID {
-			clusterListData := cluster.GetClusterList(viper.Get("roost_auth_token").(string))
+		if !isSetAlias && !isSetID && !isSetSelector {
+			clusterListData = cluster.GetClusterList(viper.Get("roost_auth_token").(string))
 			if clusterListData.Count < 1 || len(clusterListData.Clusters) < 1 {
 				fmt.Println("No clusters are found")
 				return
@@ -284,11 +1020,28 @@ var clusterDeleteCmd = &cobra.Command{
 				custToken = append(custToken, clusterData.CustomerToken)
 			}
 
-			clusterAliasInput := utils.PromptSelectInput(custToken, "Select the cluster you want to get delete")
-			if clusterAliasInput == "" {
+			clusterAliases = utils.PromptMultiSelectInput(custToken, "Select the clusters you want to delete")
+		} else if isSetID && !dryRun {
+			clusterListData = cluster.GetClusterList(viper.Get("roost_auth_token").(string))
+		}
+
+		if len(clusterAliases) == 0 {
+			return
+		}
+		if !dryRun {
+			confirmName := ""
+			if len(clusterAliases) == 1 {
+				confirmName = clusterAliases[0]
+			} else {
+				confirmName = fmt.Sprintf("%d clusters", len(clusterAliases))
+			}
+			if !utils.ConfirmDestructive(clusterDeleteSummary(clusterAliases, clusterListData.Clusters), confirmName, yes) {
+				fmt.Println("Aborted, no clusters were deleted")
 				return
 			}
-			clusterDelete(clusterAliasInput)
+		}
+		for _, clusterAlias := range clusterAliases {
+			clusterDelete(clusterAlias)
 		}
 	},
 	Example: `
@@ -297,13 +1050,90 @@ var clusterDeleteCmd = &cobra.Command{
 	roost cluster delete --id 1,2,3
 	roost cluster delete --alias ExampleAlias
 	roost cluster delete --alias ExampleAlias1. ExampleAlias2
+	roost cluster delete --alias ExampleAlias --yes
+	roost cluster delete --alias ExampleAlias --dry-run
+	roost cluster delete --selector status=failed
 	`,
 }
 
+// resolveClusterAliases turns --alias values, which may be IDs, aliases, unique prefixes or globs,
+// into the aliases of the matching clusters. It also returns the cluster list used to resolve them.
+func resolveClusterAliases(queries []string) ([]string, cluster.ClusterListResponse) {
+	clusterListData := cluster.GetClusterList(viper.Get("roost_auth_token").(string))
+	clusters, err := cluster.Resolve(clusterListData.Clusters, queries, true)
+	cobra.CheckErr(err)
+
+	var clusterAliases []string
+	for _, clusterData := range clusters {
+		clusterAliases = append(clusterAliases, clusterData.CustomerToken)
+	}
+	return clusterAliases, clusterListData
+}
+
+/*
+confirmAliasTargets resolves the --alias flag of cmd to the aliases of the clusters it names. A glob, or queries
+reaching several clusters, can change as many clusters as a selector, so they are previewed and confirmed the same
+way unless --yes is set. So are prefixes, which may name another cluster than the one meant.
+*/
+func confirmAliasTargets(cmd *cobra.Command, verb string) []string {
+	queries, _ := cmd.Flags().GetStringSlice("alias")
+	clusterListData := cluster.GetClusterList(viper.Get("roost_auth_token").(string))
+	clusters, err := cluster.Resolve(clusterListData.Clusters, queries, true)
+	cobra.CheckErr(err)
+
+	glob, prefix := false, false
+	for _, query := range queries {
+		if resolve.IsGlob(query) {
+			glob = true
+			continue
+		}
+		matches, _ := cluster.Resolve(clusterListData.Clusters, []string{query}, false)
+		if len(matches) == 1 && !cluster.IsExactMatch(matches[0], query) {
+			fmt.Printf("--alias %s matches cluster %s (ID %d)\n", query, matches[0].CustomerToken, matches[0].Id)
+			prefix = true
+		}
+	}
+	if len(clusters) > 1 || glob || prefix {
+		prompt := fmt.Sprintf("%s cluster %s?", strings.ToUpper(verb[:1])+verb[1:], clusters[0].CustomerToken)
+		if len(clusters) > 1 || glob {
+			fmt.Printf("%d clusters match --alias:\n", len(clusters))
+			printClusterTable(clusters, false, false)
+			prompt = fmt.Sprintf("%s these %d clusters?", strings.ToUpper(verb[:1])+verb[1:], len(clusters))
+		}
+		yes, _ := cmd.Flags().GetBool("yes")
+		if !yes && !utils.Confirm(prompt) {
+			fmt.Println("Aborted, nothing was changed")
+			return nil
+		}
+	}
+	var clusterAliases []string
+	for _, clusterData := range clusters {
+		clusterAliases = append(clusterAliases, clusterData.CustomerToken)
+	}
+	return clusterAliases
+}
+
+// clusterDeleteSummary describes everything deleting the given clusters removes, for the confirmation prompt.
+func clusterDeleteSummary(clusterAliases []string, clusters []cluster.ClusterList) []string {
+	var summary []string
+	for _, clusterAlias := range clusterAliases {
+		line := "cluster " + clusterAlias
+		for _, clusterData := range clusters {
+			if clusterData.CustomerToken == clusterAlias {
+				line = fmt.Sprintf("cluster %s (ID %d, %d nodes, %s)", clusterAlias, clusterData.Id, clusterData.NumNodes, clusterData.StatusMsg)
+			}
+		}
+		summary = append(summary, line)
+		summary = append(summary, cluster.LocalKubeconfigs(clusterAlias)...)
+	}
+	return summary
+}
+
 var clusterKubeconfigCmd = &cobra.Command{
 	Use:   "get-kubeconfig",
 	Short: "Get KUBECONFIG of the roost provisioned cluster",
-	Long:  `A command to get the kubeconfig of a roost provisioned cluster, provides a list of all the running clusters, the cluster for which the kubeconfig is to be downloaded can then be selected from the provided list or its ID or alias can be provided as flags. The kubeconfig file, once fetched will then be stored in '$HOME/.kube/config'`,
+	Long:  `A command to get the kubeconfig of a roost provisioned cluster, provides a list of all the running clusters, the cluster for which the kubeconfig is to be downloaded can then be selected from the provided list or its ID or alias can be provided as flags. The kubeconfig file, once fetched will then be stored in '$HOME/.kube/config'
+The kubeconfig is checked before it is written, readable only by you, so a broken download never replaces a working kubeconfig. With --verify, its API server is probed as well and the expiry of its certificates is reported.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) > 0 {
 			if args[0] != "help" {
@@ -312,41 +1142,48 @@ var clusterKubeconfigCmd = &cobra.Command{
 			cmd.Help()
 			return
 		}
-		var kubeConfigPath string
-		clusterObj := cluster.ClusterKubeconfig{}
-		home, err := os.UserHomeDir()
-		cobra.CheckErr(err)
-		kubeConfigDir := filepath.Join(home, ".kube", "roostconfig")
-		var getKubeConfigObj cluster.ClusterKubeconfigResponse
+		merge, _ := cmd.Flags().GetBool("merge")
+		switchContext, _ := cmd.Flags().GetBool("switch")
+		if switchContext && !merge {
+			cobra.CheckErr(fmt.Errorf("--switch requires --merge"))
+		}
+		verify, _ := cmd.Flags().GetBool("verify")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		failed := false
 		clusterGetKubeConfig := func(clusterAlias string) {
-			kubeConfigPath = filepath.Join(kubeConfigDir, clusterAlias)
-			clusterObj.Alias = clusterAlias
-			clusterObj.RoostAuthToken = viper.Get("roost_auth_token").(string)
 			spinner := spinner.NewSpinner()
 			spinner.Start("Getting the kubeconfig of the requested cluster")
-			apiEndPoint := "/api/application/cluster/getKubeConfig"
-			reqBuff, err := json.Marshal(clusterObj)
-			cobra.CheckErr(err)
-			body := bytes.NewReader(reqBuff)
-			status, resp, err := utils.HTTPClientRequest(http.MethodPost, apiEndPoint, "", body)
-			cobra.CheckErr(err)
-			err = json.Unmarshal(resp, &getKubeConfigObj)
-			cobra.CheckErr(err)
-			if !utils.FileOrFolderExists(kubeConfigDir) {
-				err := os.MkdirAll(kubeConfigDir, 0755)
-				cobra.CheckErr(err)
-			}
-
-			err = os.WriteFile(kubeConfigPath, []byte(getKubeConfigObj.Kubeconfig), 0644)
-			cobra.CheckErr(err)
-			var apiresp cluster.ClusterApiResponse
-			if status == http.StatusCreated {
+			authToken := viper.Get("roost_auth_token").(string)
+			if merge {
+				kubeConfigPath, err := cluster.MergeKubeconfig(authToken, clusterAlias, switchContext)
+				if err != nil {
+					spinner.Stop(false)
+					fmt.Println(err)
+					failed = true
+					return
+				}
 				spinner.Stop(true)
-				fmt.Printf("The kubeconfig file is present in $HOME/.kube/roostconfig/%s.\nUse 'export KUBECONFIG=$HOME/.kube/roostconfig/%s'.\n", clusterObj.Alias, clusterObj.Alias)
-			} else {
+				if switchContext {
+					fmt.Printf("Merged into %s as context %s, which is now the current context.\n", kubeConfigPath, cluster.ContextName(clusterAlias))
+				} else {
+					fmt.Printf("Merged into %s as context %s.\nUse 'kubectl config use-context %s'.\n", kubeConfigPath, cluster.ContextName(clusterAlias), cluster.ContextName(clusterAlias))
+				}
+				if verify && !verifyKubeconfig(kubeConfigPath, cluster.ContextName(clusterAlias), timeout) {
+					failed = true
+				}
+				return
+			}
+			kubeConfigPath, err := cluster.SaveKubeconfig(authToken, clusterAlias)
+			if err != nil {
 				spinner.Stop(false)
-				json.Unmarshal(resp, &apiresp)
-				fmt.Println("Unable to get the kubeconfig of the requested cluster: ", string(apiresp.ClusterRespMessage))
+				fmt.Println(err)
+				failed = true
+				return
+			}
+			spinner.Stop(true)
+			fmt.Printf("The kubeconfig file is present in $HOME/.kube/roostconfig/%s.\nUse 'export KUBECONFIG=$HOME/.kube/roostconfig/%s'.\n", clusterAlias, clusterAlias)
+			if verify && !verifyKubeconfig(kubeConfigPath, "", timeout) {
+				failed = true
 			}
 		}
 
@@ -363,12 +1200,21 @@ var clusterKubeconfigCmd = &cobra.Command{
 		isSetAlias := cmd.Flags().Lookup("alias").Changed
 		if isSetAlias {
 			ClusterAliasArr, _ := cmd.Flags().GetStringSlice("alias")
-			for _, ClusterAlias := range ClusterAliasArr {
+			clusterAliases, _ := resolveClusterAliases(ClusterAliasArr)
+			for _, ClusterAlias := range clusterAliases {
 				clusterGetKubeConfig(ClusterAlias)
 			}
 		}
 
-		if !isSetAlias && !isSetID {
+		isSetSelector := isSelectorSet(cmd)
+		if isSetSelector {
+			clusters, _ := selectClustersForBulk(cmd)
+			for _, clusterData := range clusters {
+				clusterGetKubeConfig(clusterData.CustomerToken)
+			}
+		}
+
+		if !isSetAlias && !isSetID && !isSetSelector {
 			clusterListData := cluster.GetClusterList(viper.Get("roost_auth_token").(string))
 			if clusterListData.Count < 1 || len(clusterListData.Clusters) < 1 {
 				fmt.Println("No clusters are found")
@@ -385,12 +1231,13 @@ var clusterKubeconfigCmd = &cobra.Command{
 				return
 			}
 
-			clusterAliasInput := utils.PromptSelectInput(custToken, "Select the cluster you want to get kubeconfig of")
-
-			if clusterAliasInput == "" {
-				return
+			clusterAliasInputs := utils.PromptMultiSelectInput(custToken, "Select the clusters you want to get kubeconfig of")
+			for _, clusterAliasInput := range clusterAliasInputs {
+				clusterGetKubeConfig(clusterAliasInput)
 			}
-			clusterGetKubeConfig(clusterAliasInput)
+		}
+		if failed {
+			os.Exit(1)
 		}
 	},
 	Example: `
@@ -399,9 +1246,45 @@ var clusterKubeconfigCmd = &cobra.Command{
 	roost cluster get-kubeconfig --id 1,2,3
 	roost cluster get-kubeconfig --alias ExampleAlias
 	roost cluster get-kubeconfig --alias ExampleAlias1. ExampleAlias2
+	roost cluster get-kubeconfig --selector status=running
+	roost cluster get-kubeconfig --alias ExampleAlias --merge --switch
+	roost cluster get-kubeconfig --alias ExampleAlias --verify
 	`,
 }
 
+// certificateWarning is how close to its expiry a certificate is reported by --verify.
+const certificateWarning = 7 * 24 * time.Hour
+
+// verifyKubeconfig probes the API server of a context of a downloaded kubeconfig and prints what it found. It reports
+// whether the kubeconfig is usable.
+func verifyKubeconfig(path, contextName string, timeout time.Duration) bool {
+	kubeConfig, err := kubeconfig.Load(path)
+	if err != nil {
+		fmt.Println("Unable to verify the kubeconfig:", err.Error())
+		return false
+	}
+	health, err := kubeconfig.Verify(kubeConfig, contextName, timeout)
+	if err == nil {
+		fmt.Printf("The API server %s is reachable, Kubernetes %s.\n", health.Server, health.Version)
+	}
+	for _, cert := range health.Certificates {
+		left := time.Until(cert.NotAfter)
+		switch {
+		case left <= 0:
+			fmt.Printf("Warning: the %s expired on %s.\n", cert.Name, cert.NotAfter.Local().Format(time.RFC1123))
+		case left < certificateWarning:
+			fmt.Printf("Warning: the %s expires in %s, on %s.\n", cert.Name, utils.HumanDuration(left), cert.NotAfter.Local().Format(time.RFC1123))
+		default:
+			fmt.Printf("The %s expires in %s, on %s.\n", cert.Name, utils.HumanDuration(left), cert.NotAfter.Local().Format(time.RFC1123))
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+	return true
+}
+
 var clusterListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "A command to get the list of Roost cluster",
@@ -415,51 +1298,272 @@ var clusterListCmd = &cobra.Command{
 			cmd.Help()
 			return
 		}
+		output, _ := cmd.Flags().GetString("output")
+		if output != "table" && output != "wide" && output != "json" {
+			cobra.CheckErr(fmt.Errorf("unknown output format %q, use table, wide or json", output))
+		}
+		if watch, _ := cmd.Flags().GetBool("watch"); watch {
+			if output != "json" {
+				cobra.CheckErr(fmt.Errorf("--watch requires -o json"))
+			}
+			interval, _ := cmd.Flags().GetDuration("interval")
+			watchClusterList(cmd, interval)
+			return
+		}
+
 		clusterListData := cluster.GetClusterList(viper.Get("roost_auth_token").(string))
-		if clusterListData.Count > 0 {
-
-			t := table.NewWriter()
-			t.SetOutputMirror(os.Stdout)
-			t.AppendHeader(table.Row{"ID", "Cluster Alias", "Email", "Public IP", "Nodes", "Cluster Start Time", "Status"})
-			t.SetStyle(table.StyleDouble)
-			flagRunning, _ := cmd.Flags().GetBool("running")
-			flagStopped, _ := cmd.Flags().GetBool("stopped")
-			if flagRunning == true {
-				for _, clusterData := range clusterListData.Clusters {
-					if clusterData.IsActive == true {
-						t.AppendRows([]table.Row{
-							{clusterData.Id, clusterData.CustomerToken, clusterData.CustomerEmail, clusterData.PublicIP, clusterData.NumNodes, clusterData.CreatedOn, clusterData.StatusMsg},
-						})
-					}
-				}
-			} else if flagStopped == true {
-				for _, clusterData := range clusterListData.Clusters {
-					if clusterData.StatusMsg == "Stopped ..." {
-						t.AppendRows([]table.Row{
-							{clusterData.Id, clusterData.CustomerToken, clusterData.CustomerEmail, clusterData.PublicIP, clusterData.NumNodes, clusterData.CreatedOn, clusterData.StatusMsg},
-						})
-					}
-				}
-			} else {
-				for _, clusterData := range clusterListData.Clusters {
-					t.AppendRows([]table.Row{
-						{clusterData.Id, clusterData.CustomerToken, clusterData.CustomerEmail, clusterData.PublicIP, clusterData.NumNodes, clusterData.CreatedOn, clusterData.StatusMsg},
-					})
-				}
+		cluster.PruneMetadata(clusterListData.Clusters)
+		clusters, err := filterClusterList(cmd, clusterListData.Clusters)
+		cobra.CheckErr(err)
+		if sortBy, _ := cmd.Flags().GetString("sort-by"); sortBy != "" {
+			reverse, _ := cmd.Flags().GetBool("reverse")
+			records, _ := cluster.LoadRecords()
+			cobra.CheckErr(cluster.SortList(clusters, sortBy, reverse, records, time.Now()))
+		}
+		if limit, _ := cmd.Flags().GetInt("limit"); limit > 0 && len(clusters) > limit {
+			clusters = clusters[:limit]
+		}
+		if output == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			// The local labels and annotations are added to the fields of the API.
+			type labeledCluster struct {
+				cluster.ClusterList
+				Labels      map[string]string `json:"labels,omitempty"`
+				Annotations map[string]string `json:"annotations,omitempty"`
+			}
+			metadata, _ := cluster.LoadMetadata()
+			labeled := []labeledCluster{}
+			for _, clusterData := range clusters {
+				entry := metadata.Lookup(clusterData)
+				labeled = append(labeled, labeledCluster{clusterData, entry.Labels, entry.Annotations})
 			}
+			cobra.CheckErr(encoder.Encode(labeled))
+			return
+		}
+		if clusterListData.Count > 0 && len(clusters) == 0 {
+			fmt.Println("No clusters match the filters")
+		} else if clusterListData.Count > 0 {
+			absoluteTimes, _ := cmd.Flags().GetBool("absolute-times")
+			printClusterTable(clusters, output == "wide", absoluteTimes)
+		} else {
+			fmt.Println("No clusters found. Use 'roost cluster create' command to create a new roost cluster.")
+		}
+	},
+	Example: `
+	roost cluster list
+	roost cluster list --running
+	roost cluster list --stopped
+	roost cluster list --status failed,in-progress
+	roost cluster list --name 'ci-*' --created-before 2d --sort-by created --reverse --limit 10
+	roost cluster list --email '*@ourco.com' --type roost -o wide
+	roost cluster list --created-after '2023-05-01 09:00' --absolute-times
+	roost cluster list --selector status=failed
+	roost cluster list --selector 'email=ci@ourco.com,age>4h'
+	roost cluster list --selector project=checkout
+	roost cluster list --field-selector 'cluster_type=roost,num_nodes>=2'
+	roost cluster list -o json
+	roost cluster list -o json --watch --selector email=ci@ourco.com
+	`,
+}
+
+// filterClusterList applies the filter and selector flags of the list command.
+func filterClusterList(cmd *cobra.Command, clusterList []cluster.ClusterList) ([]cluster.ClusterList, error) {
+	var filter cluster.ListFilter
+	filter.Statuses, _ = cmd.Flags().GetStringSlice("status")
+	if running, _ := cmd.Flags().GetBool("running"); running {
+		filter.Statuses = append(filter.Statuses, cluster.StatusRunning)
+	}
+	if stopped, _ := cmd.Flags().GetBool("stopped"); stopped {
+		filter.Statuses = append(filter.Statuses, cluster.StatusStopped)
+	}
+	filter.Types, _ = cmd.Flags().GetStringSlice("type")
+	filter.Envs, _ = cmd.Flags().GetStringSlice("env")
+	filter.Email, _ = cmd.Flags().GetString("email")
+	filter.Name, _ = cmd.Flags().GetString("name")
+	now := time.Now()
+	for flag, t := range map[string]*time.Time{"created-before": &filter.CreatedBefore, "created-after": &filter.CreatedAfter} {
+		value, _ := cmd.Flags().GetString(flag)
+		if value == "" {
+			continue
+		}
+		parsed, err := utils.ParseUserTime(value, now)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %s", flag, err.Error())
+		}
+		*t = parsed
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return selectClusters(cmd, filter.Filter(clusterList))
+}
+
+// clusterEvent is a line of 'roost cluster list -o json --watch'.
+type clusterEvent struct {
+	Type    string              `json:"type"`
+	Cluster cluster.ClusterList `json:"cluster"`
+}
+
+// watchClusterList polls the cluster list and prints a JSON line for every cluster which was added, modified or deleted.
+// The first poll reports every cluster as added. Failed polls are reported on stderr and retried.
+func watchClusterList(cmd *cobra.Command, interval time.Duration) {
+	authToken := viper.Get("roost_auth_token").(string)
+	encoder := json.NewEncoder(os.Stdout)
+	previous := map[int]cluster.ClusterList{}
+	for ; ; time.Sleep(interval) {
+		list, err := cluster.FetchClusterList(authToken)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		clusters, err := filterClusterList(cmd, list.Clusters)
+		cobra.CheckErr(err)
+
+		current := map[int]cluster.ClusterList{}
+		for _, clusterData := range clusters {
+			current[clusterData.Id] = clusterData
+			old, ok := previous[clusterData.Id]
+			switch {
+			case !ok:
+				cobra.CheckErr(encoder.Encode(clusterEvent{Type: "ADDED", Cluster: clusterData}))
+			case old != clusterData:
+				cobra.CheckErr(encoder.Encode(clusterEvent{Type: "MODIFIED", Cluster: clusterData}))
+			}
+		}
+		for id, clusterData := range previous {
+			if _, ok := current[id]; !ok {
+				cobra.CheckErr(encoder.Encode(clusterEvent{Type: "DELETED", Cluster: clusterData}))
+			}
+		}
+		previous = current
+	}
+}
+
+var clusterWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch the Roost clusters in a live dashboard",
+	Long: `A command to show the roost clusters in a full screen table which is refreshed on an interval. Status changes are highlighted and failed clusters are flagged.
+The selected cluster can be stopped (s) or deleted (d), both confirmed with y, have its kubeconfig downloaded (k), its UI opened (o) or its details shown (enter).
+With the require_typed_confirmation setting or --confirm-name, deletes are confirmed by typing the alias instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		interval, _ := cmd.Flags().GetDuration("interval")
+		var filter cluster.Filter
+		if isSelectorSet(cmd) {
+			filter = func(clusters []cluster.ClusterList) ([]cluster.ClusterList, error) {
+				return selectClusters(cmd, clusters)
+			}
+		}
+		cobra.CheckErr(cluster.RunDashboard(viper.Get("roost_auth_token").(string), interval, filter))
+	},
+	Example: `
+	roost cluster watch
+	roost cluster watch --interval 30s
+	roost cluster watch --selector email=ci@ourco.com
+	`,
+}
+
+// printClusterTable prints the clusters as a table. wide adds the columns which are usually hidden. Times are relative,
+// e.g. 3h ago, unless absoluteTimes is set, then they are shown in the local time zone.
+func printClusterTable(clusters []cluster.ClusterList, wide, absoluteTimes bool) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	// The expiry is only known for clusters created from this machine, and labels are kept locally.
+	records, _ := cluster.LoadRecords()
+	metadata, _ := cluster.LoadMetadata()
+	showLabels := false
+	for _, clusterData := range clusters {
+		showLabels = showLabels || len(metadata.Lookup(clusterData).Labels) > 0
+	}
+	header := table.Row{"ID", "Cluster Alias", "Email", "Public IP", "Nodes", "Created", "Status", "Time Left"}
+	if showLabels || wide {
+		header = append(header, "Labels")
+	}
+	if wide {
+		header = append(header, "Type", "Env", "Running Since", "Stopped At", "Failure", "Annotations")
+	}
+	t.AppendHeader(header)
+	t.SetStyle(table.StyleDouble)
+	now := time.Now()
+	formatTime := func(value string) string {
+		parsed, err := utils.ParseTime(value)
+		if err != nil {
+			return value
+		}
+		if absoluteTimes {
+			return parsed.Local().Format("2006-01-02 15:04 MST")
+		}
+		return utils.Ago(parsed, now)
+	}
+	for _, clusterData := range clusters {
+		timeLeft := "-"
+		if left, ok := records.ExpiresIn(clusterData, now); ok {
+			timeLeft = utils.HumanDuration(left)
+			if left <= 0 {
+				timeLeft = "expired"
+			}
+		}
+		row := table.Row{clusterData.Id, clusterData.CustomerToken, clusterData.CustomerEmail, clusterData.PublicIP, clusterData.NumNodes, formatTime(clusterData.CreatedOn), clusterData.StatusMsg, timeLeft}
+		if showLabels || wide {
+			row = append(row, cluster.FormatMetadata(metadata.Lookup(clusterData).Labels))
+		}
+		if wide {
+			row = append(row, clusterData.ClusterType, clusterData.EnvType, formatTime(clusterData.RunningOn), formatTime(clusterData.StoppedOn), clusterData.FailureMsg, cluster.FormatMetadata(metadata.Lookup(clusterData).Annotations))
+		}
+		t.AppendRow(row)
+	}
+
+	fmt.Print("\n")
+	t.Render()
+	fmt.Print("\n")
+}
+
+// addSelectorFlags adds the --selector and --field-selector flags used to pick clusters in bulk.
+func addSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("selector", "l", "", "Select clusters by attributes: id, alias, email, status (running, stopped, failed, in-progress), type, env, nodes, ip, age and the labels set with 'roost cluster label', e.g. 'status=running,email=ci@ourco.com,age>4h,project=checkout'")
+	cmd.Flags().String("field-selector", "", "Select clusters by their list fields, e.g. 'is_active=true,cluster_type=roost,num_nodes>1,age>2d'")
+}
+
+// isSelectorSet reports whether any of the selector flags were given.
+func isSelectorSet(cmd *cobra.Command) bool {
+	return cmd.Flags().Lookup("selector").Changed || cmd.Flags().Lookup("field-selector").Changed
+}
 
-			fmt.Print("\n")
-			t.Render()
-			fmt.Print("\n")
-		} else {
-			fmt.Println("No clusters found. Use 'roost cluster create' command to create a new roost cluster.")
-		}
-	},
-	Example: `
-	roost cluster list
-	roost cluster list --running
-	roost cluster list --stopped
-	`,
+// selectClusters filters the clusters with the --selector and --field-selector flags of cmd.
+func selectClusters(cmd *cobra.Command, clusters []cluster.ClusterList) ([]cluster.ClusterList, error) {
+	labelSelector, _ := cmd.Flags().GetString("selector")
+	fieldSelector, _ := cmd.Flags().GetString("field-selector")
+	return cluster.Select(clusters, labelSelector, fieldSelector)
+}
+
+// selectClustersForBulk fetches the cluster list and previews the clusters matched by the selector flags of cmd.
+// It returns nothing when no cluster matches. Selectors without requirements, such as one from an unset variable, are
+// rejected rather than matching every cluster.
+func selectClustersForBulk(cmd *cobra.Command) ([]cluster.ClusterList, cluster.ClusterListResponse) {
+	labelSelector, _ := cmd.Flags().GetString("selector")
+	fieldSelector, _ := cmd.Flags().GetString("field-selector")
+	all, err := cluster.SelectsAll(labelSelector, fieldSelector)
+	cobra.CheckErr(err)
+	if all {
+		cobra.CheckErr(fmt.Errorf("the selector is empty and would match every cluster, give a requirement such as status=running"))
+	}
+	clusterListData := cluster.GetClusterList(viper.Get("roost_auth_token").(string))
+	clusters, err := selectClusters(cmd, clusterListData.Clusters)
+	cobra.CheckErr(err)
+	if len(clusters) == 0 {
+		fmt.Println("No clusters match the selector")
+		return nil, clusterListData
+	}
+	fmt.Printf("%d clusters match the selector:\n", len(clusters))
+	printClusterTable(clusters, false, false)
+	return clusters, clusterListData
 }
 
 var clusterDetailsCmd = &cobra.Command{
@@ -567,7 +1671,11 @@ var clusterDetailsCmd = &cobra.Command{
 var clusterUICmd = &cobra.Command{
 	Use:   "ui",
 	Short: "Connect to roost service fitness for a specific cluster",
-	Long:  `A command to display service fitness UI of roost.ai for a specific cluster`,
+	Long: `A command to display service fitness UI of roost.ai for a specific cluster.
+The UI is expected at http://<public IP>:30070/app, the ui_scheme, ui_port and ui_path settings of the config file change
+that, and the --scheme, --port and --path flags override them. The UI opens in the browser set by the browser setting or
+$BROWSER, the default browser otherwise. The UI is checked to be reachable first. If the NodePort of the UI is firewalled,
+--port-forward reaches it through kubectl port-forward instead, which runs until interrupted.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) > 0 {
 			if args[0] != "help" {
@@ -576,23 +1684,19 @@ var clusterUICmd = &cobra.Command{
 			cmd.Help()
 			return
 		}
-		isSetID := cmd.Flags().Lookup("id").Changed
+		var clusterData cluster.ClusterList
 		var err error
+		isSetID := cmd.Flags().Lookup("id").Changed
+		isSetAlias := cmd.Flags().Lookup("alias").Changed
 		if isSetID {
 			ClusterID, _ := cmd.Flags().GetInt32("id")
-			clusterInfo, err := cluster.GetClusterDetails(int(ClusterID), "")
+			clusterData, err = cluster.GetClusterDetails(int(ClusterID), "")
 			cobra.CheckErr(err)
-			clusterIP := clusterInfo.PublicIP
-			err = utils.Openbrowser("http://" + clusterIP + ":30070/app")
 		}
-
-		isSetAlias := cmd.Flags().Lookup("alias").Changed
 		if isSetAlias {
 			clusterAlias, _ := cmd.Flags().GetString("alias")
-			clusterInfo, err := cluster.GetClusterDetails(-1, clusterAlias)
+			clusterData, err = cluster.GetClusterDetails(-1, clusterAlias)
 			cobra.CheckErr(err)
-			clusterIP := clusterInfo.PublicIP
-			err = utils.Openbrowser("http://" + clusterIP + ":30070/app")
 		}
 
 		if !isSetID && !isSetAlias {
@@ -613,68 +1717,1004 @@ var clusterUICmd = &cobra.Command{
 			}
 
 			clusterAliasInput := utils.PromptSelectInput(custToken, "Select the cluster you want to get connect UI")
-			if clusterAliasInput != "" {
-				for _, clusterData := range clusterListData.Clusters {
-					if clusterData.CustomerToken == clusterAliasInput {
-						clusterIP := clusterData.PublicIP
-						err = utils.Openbrowser("http://" + clusterIP + ":30070/app")
-					}
+			found := false
+			for _, listed := range clusterListData.Clusters {
+				if listed.CustomerToken == clusterAliasInput {
+					clusterData, found = listed, true
 				}
 			}
+			if !found {
+				return
+			}
 		}
-		cobra.CheckErr(err)
+		openClusterUI(cmd, clusterData)
 	},
 	Example: `
 	roost cluster ui
 	roost cluster ui --id 1
 	roost cluster ui --alias exampleAlias
+	roost cluster ui --alias exampleAlias --print-url
+	roost cluster ui --alias exampleAlias --port 8443 --scheme https --path /
+	roost cluster ui --alias exampleAlias --port-forward --no-browser
+	`,
+}
+
+// openClusterUI shows the UI of a cluster as asked by the flags of the ui command.
+func openClusterUI(cmd *cobra.Command, clusterData cluster.ClusterList) {
+	endpoint := cluster.DefaultUIEndpoint()
+	if cmd.Flags().Lookup("scheme").Changed {
+		endpoint.Scheme, _ = cmd.Flags().GetString("scheme")
+	}
+	if cmd.Flags().Lookup("port").Changed {
+		endpoint.Port, _ = cmd.Flags().GetInt("port")
+	}
+	if cmd.Flags().Lookup("path").Changed {
+		endpoint.Path, _ = cmd.Flags().GetString("path")
+	}
+	if browser, _ := cmd.Flags().GetString("browser"); browser != "" {
+		viper.Set("browser", browser)
+	}
+	noBrowser, _ := cmd.Flags().GetBool("no-browser")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	if printURL, _ := cmd.Flags().GetBool("print-url"); printURL {
+		fmt.Println(endpoint.URL(clusterData.PublicIP))
+		return
+	}
+
+	if portForward, _ := cmd.Flags().GetBool("port-forward"); portForward {
+		localPort, _ := cmd.Flags().GetInt("local-port")
+		authToken := viper.Get("roost_auth_token").(string)
+		kubeConfigPath, cleanup, err := cluster.ExecKubeconfig(authToken, clusterData.CustomerToken, "")
+		cobra.CheckErr(err)
+		defer cleanup()
+		service, err := cluster.FindUIService(kubeConfigPath, endpoint.Port)
+		cobra.CheckErr(err)
+		forward, err := cluster.PortForward(kubeConfigPath, service, localPort, timeout)
+		cobra.CheckErr(err)
+
+		interrupts := make(chan os.Signal, 1)
+		signal.Notify(interrupts, os.Interrupt)
+		defer signal.Stop(interrupts)
+		go func() {
+			<-interrupts
+			forward.Stop()
+		}()
+
+		endpoint.Port = forward.LocalPort
+		showUI(endpoint.URL("127.0.0.1"), noBrowser)
+		fmt.Printf("Forwarding 127.0.0.1:%d to service %s/%s port %d, press Ctrl-C to stop.\n", forward.LocalPort, service.Namespace, service.Name, service.Port)
+		forward.Wait()
+		return
+	}
+
+	url := endpoint.URL(clusterData.PublicIP)
+	if noCheck, _ := cmd.Flags().GetBool("no-check"); !noCheck {
+		if err := kubeconfig.Reachable(url, timeout); err != nil {
+			cobra.CheckErr(fmt.Errorf("the UI of cluster %s at %s is not reachable: %s\nIf its NodePort is firewalled, use --port-forward", clusterData.CustomerToken, url, err.Error()))
+		}
+	}
+	showUI(url, noBrowser)
+}
+
+// showUI opens url in the browser, or prints it when there's no browser to open.
+func showUI(url string, noBrowser bool) {
+	if noBrowser {
+		fmt.Println("The UI is available at", url)
+		return
+	}
+	if err := utils.Openbrowser(url); err != nil {
+		fmt.Printf("Unable to open a browser: %s\nThe UI is available at %s\n", err.Error(), url)
+		return
+	}
+	fmt.Println("Opened", url)
+}
+
+
+var clusterExecCmd = &cobra.Command{
+	Use:   "exec [alias] -- command [args...]",
+	Short: "Run a command against a Roost cluster",
+	Long: `A command to run a tool such as kubectl or helm against a roost cluster without exporting KUBECONFIG. The kubeconfig
+downloaded by 'roost cluster get-kubeconfig' is reused, or downloaded first, and the command runs with KUBECONFIG pointing
+to it. The namespace the cluster was created with from this machine, or --namespace, is the namespace of its context.
+Without an alias the cluster can be selected from the running clusters. With --all-running the command runs against every
+running cluster at once, each line of its output prefixed with the alias of the cluster.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dash := cmd.ArgsLenAtDash()
+		if dash < 0 || dash == len(args) {
+			fmt.Println("The command to run has to follow --")
+			cmd.Help()
+			return
+		}
+		allRunning, _ := cmd.Flags().GetBool("all-running")
+		if dash > 1 || (allRunning && dash > 0) {
+			cobra.CheckErr(fmt.Errorf("exec takes one alias, or --all-running, before --"))
+		}
+		namespace, _ := cmd.Flags().GetString("namespace")
+		authToken := viper.Get("roost_auth_token").(string)
+		command := args[dash:]
+
+		if !allRunning {
+			clusterAlias := execTarget(authToken, args[:dash])
+			if clusterAlias == "" {
+				return
+			}
+			os.Exit(runWithKubeconfig(authToken, clusterAlias, namespace, command, os.Stdin, os.Stdout, os.Stderr))
+		}
+
+		clusterListData, err := cluster.FetchClusterList(authToken)
+		cobra.CheckErr(err)
+		var clusterAliases []string
+		width := 0
+		for _, clusterData := range clusterListData.Clusters {
+			if cluster.Status(clusterData) == cluster.StatusRunning {
+				clusterAliases = append(clusterAliases, clusterData.CustomerToken)
+				if len(clusterData.CustomerToken) > width {
+					width = len(clusterData.CustomerToken)
+				}
+			}
+		}
+		if len(clusterAliases) == 0 {
+			fmt.Println("No running clusters are found")
+			return
+		}
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		exitCodes := make([]int, len(clusterAliases))
+		for i, clusterAlias := range clusterAliases {
+			wg.Add(1)
+			go func(i int, clusterAlias string) {
+				defer wg.Done()
+				prefix := fmt.Sprintf("[%-*s] ", width, clusterAlias)
+				stdout := utils.NewPrefixWriter(os.Stdout, prefix, &mu)
+				stderr := utils.NewPrefixWriter(os.Stderr, prefix, &mu)
+				exitCodes[i] = runWithKubeconfig(authToken, clusterAlias, namespace, command, nil, stdout, stderr)
+				stdout.Flush()
+				stderr.Flush()
+			}(i, clusterAlias)
+		}
+		wg.Wait()
+
+		var failed []string
+		for i, clusterAlias := range clusterAliases {
+			if exitCodes[i] != 0 {
+				failed = append(failed, fmt.Sprintf("%s (exit code %d)", clusterAlias, exitCodes[i]))
+			}
+		}
+		if len(failed) > 0 {
+			fmt.Fprintln(os.Stderr, "The command failed on:", strings.Join(failed, ", "))
+			os.Exit(1)
+		}
+	},
+	Example: `
+	roost cluster exec ExampleAlias -- kubectl get pods
+	roost cluster exec ExampleAlias -n kube-system -- kubectl get pods
+	roost cluster exec -- helm list
+	roost cluster exec --all-running -- kubectl get nodes
+	`,
+}
+
+var clusterShellCmd = &cobra.Command{
+	Use:   "shell [alias]",
+	Short: "Start a shell set up for a Roost cluster",
+	Long: `A command to start $SHELL with KUBECONFIG pointing to the kubeconfig of a roost cluster, like 'roost cluster exec'.
+ROOST_CLUSTER is set to the alias of the cluster. Exit the shell to return.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 1 {
+			cobra.CheckErr(fmt.Errorf("shell takes at most one alias"))
+		}
+		namespace, _ := cmd.Flags().GetString("namespace")
+		authToken := viper.Get("roost_auth_token").(string)
+		clusterAlias := execTarget(authToken, args)
+		if clusterAlias == "" {
+			return
+		}
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		fmt.Printf("Starting %s for cluster %s, exit to return.\n", shell, clusterAlias)
+		os.Exit(runWithKubeconfig(authToken, clusterAlias, namespace, []string{shell}, os.Stdin, os.Stdout, os.Stderr))
+	},
+	Example: `
+	roost cluster shell
+	roost cluster shell ExampleAlias
+	roost cluster shell ExampleAlias -n kube-system
+	`,
+}
+
+// execTarget resolves the alias given to exec or shell, or lets the user select a running cluster without one.
+func execTarget(authToken string, args []string) string {
+	clusterListData, err := cluster.FetchClusterList(authToken)
+	cobra.CheckErr(err)
+	if len(args) == 0 {
+		var clusterNames []string
+		for _, clusterData := range clusterListData.Clusters {
+			if cluster.Status(clusterData) == cluster.StatusRunning {
+				clusterNames = append(clusterNames, clusterData.CustomerToken)
+			}
+		}
+		if len(clusterNames) == 0 {
+			fmt.Println("No running clusters are found")
+			return ""
+		}
+		return utils.PromptSelectInput(clusterNames, "Select the cluster to run the command against")
+	}
+	clusters, err := cluster.Resolve(clusterListData.Clusters, args, false)
+	cobra.CheckErr(err)
+	if status := cluster.Status(clusters[0]); status != cluster.StatusRunning {
+		cobra.CheckErr(fmt.Errorf("cluster %s is %s, it has to be running", clusters[0].CustomerToken, status))
+	}
+	return clusters[0].CustomerToken
+}
+
+// runWithKubeconfig runs command with KUBECONFIG set for the cluster and returns its exit code.
+// Problems preparing the kubeconfig or starting the command are written to stderr.
+func runWithKubeconfig(authToken, clusterAlias, namespace string, command []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if namespace == "" {
+		namespace = cluster.Namespace(clusterAlias)
+	}
+	kubeConfigPath, cleanup, err := cluster.ExecKubeconfig(authToken, clusterAlias, namespace)
+	if err != nil {
+		fmt.Fprintln(stderr, "Unable to get the kubeconfig:", err.Error())
+		return 1
+	}
+	defer cleanup()
+
+	child := exec.Command(command[0], command[1:]...)
+	child.Env = append(os.Environ(), "KUBECONFIG="+kubeConfigPath, "ROOST_CLUSTER="+clusterAlias)
+	child.Stdin, child.Stdout, child.Stderr = stdin, stdout, stderr
+
+	// Interrupts reach the child directly, roost only waits for it to exit and cleans up.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	err = child.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 127
+	}
+	return 0
+}
+
+var clusterLabelCmd = &cobra.Command{
+	Use:   "label alias key=value... [key-...]",
+	Short: "Label Roost clusters",
+	Long: `A command to set or remove labels of roost clusters. Labels are kept on this machine, next to the config, and can
+be used with --selector like the built-in attributes, e.g. 'roost cluster stop --selector project=checkout'. The alias
+accepts IDs, unique prefixes and globs such as 'ci-*'. Without key=value arguments the labels of the clusters are shown.
+Labels of clusters which no longer exist are dropped by the next 'roost cluster list', label or annotate.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runMetadataCmd(cmd, args, cluster.MetadataLabels)
+	},
+	Example: `
+	roost cluster label ExampleAlias project=checkout owner=payments
+	roost cluster label 'ci-*' tier=ci
+	roost cluster label ExampleAlias project=search --overwrite
+	roost cluster label ExampleAlias owner-
+	roost cluster label ExampleAlias
+	`,
+}
+
+var clusterAnnotateCmd = &cobra.Command{
+	Use:   "annotate alias key=value... [key-...]",
+	Short: "Annotate Roost clusters",
+	Long: `A command to set or remove annotations of roost clusters, free form notes such as the ticket a cluster was created
+for. Annotations are kept on this machine like labels, but can't be used with --selector. Without key=value arguments the
+annotations of the clusters are shown.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runMetadataCmd(cmd, args, cluster.MetadataAnnotations)
+	},
+	Example: `
+	roost cluster annotate ExampleAlias ticket=OPS-1234 'note=demo for the sales team'
+	roost cluster annotate ExampleAlias note-
+	roost cluster annotate ExampleAlias
+	`,
+}
+
+// runMetadataCmd sets, removes or shows the labels or annotations of the clusters matching the first argument.
+func runMetadataCmd(cmd *cobra.Command, args []string, kind string) {
+	if len(args) == 0 {
+		cmd.Help()
+		return
+	}
+	changes, err := cluster.ParseMetadataChanges(kind, args[1:])
+	cobra.CheckErr(err)
+	clusterListData, err := cluster.FetchClusterList(viper.Get("roost_auth_token").(string))
+	cobra.CheckErr(err)
+	clusters, err := cluster.Resolve(clusterListData.Clusters, args[:1], true)
+	cobra.CheckErr(err)
+	store, err := cluster.LoadMetadata()
+	cobra.CheckErr(err)
+
+	if len(changes) == 0 {
+		for _, clusterData := range clusters {
+			fmt.Printf("%s: %s\n", clusterData.CustomerToken, cluster.FormatMetadata(store.Lookup(clusterData).Get(kind)))
+		}
+		return
+	}
+	overwrite, _ := cmd.Flags().GetBool("overwrite")
+	if len(clusterListData.Clusters) > 0 {
+		store.Prune(clusterListData.Clusters)
+	}
+	for _, clusterData := range clusters {
+		cobra.CheckErr(store.Apply(clusterData, kind, changes, overwrite))
+	}
+	cobra.CheckErr(store.Save())
+	verb := "Labeled"
+	if kind == cluster.MetadataAnnotations {
+		verb = "Annotated"
+	}
+	for _, clusterData := range clusters {
+		fmt.Printf("%s cluster %s: %s\n", verb, clusterData.CustomerToken, cluster.FormatMetadata(store.Lookup(clusterData).Get(kind)))
+	}
+}
+
+var clusterPresetCmd = &cobra.Command{
+	Use:   "preset",
+	Short: "Manage saved cluster presets",
+	Long: `Presets are saved shapes of roost clusters, applied with 'roost cluster create --preset <name>'. Each preset is a
+plain YAML file with the keys of cluster spec files, saved in presets next to the config. Presets can be shared by a team
+by keeping the files in a shared directory listed in the preset_path setting (separated like $PATH), or by passing the
+path of a preset file to --preset.`,
+}
+
+var clusterPresetSaveCmd = &cobra.Command{
+	Use:   "save name",
+	Short: "Save a cluster preset",
+	Long: `A command to save the create flags, or the parameters an existing cluster was created with from this machine,
+as a preset. With --from-cluster, the flags which are given override the parameters of the cluster.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			cobra.CheckErr(fmt.Errorf("save takes the name of the preset"))
+		}
+		request := cluster.CreateClusterRequest{}
+		if from, _ := cmd.Flags().GetString("from-cluster"); from != "" {
+			records, err := cluster.LoadRecords()
+			cobra.CheckErr(err)
+			// An alias with a record needs no lookup, so presets can still be saved from deleted clusters.
+			if _, ok := records[from]; !ok {
+				clusterListData, err := cluster.FetchClusterList(viper.Get("roost_auth_token").(string))
+				cobra.CheckErr(err)
+				clusters, err := cluster.Resolve(clusterListData.Clusters, []string{from}, false)
+				cobra.CheckErr(err)
+				from = clusters[0].CustomerToken
+			}
+			record, ok := records[from]
+			if !ok || record.Request.InstanceType == "" {
+				cobra.CheckErr(fmt.Errorf("cluster %s wasn't created from this machine, so its parameters aren't known", from))
+			}
+			request = record.Request
+			request.Alias = ""
+		}
+		request = presetFlags(cmd, request)
+
+		preset := cluster.Preset{Name: args[0], Request: request}
+		cobra.CheckErr(cluster.CheckPresetName(preset.Name))
+		cobra.CheckErr(preset.Validate())
+		overwrite, _ := cmd.Flags().GetBool("overwrite")
+		path, err := cluster.SavePreset(preset.Name, request, overwrite)
+		cobra.CheckErr(err)
+		fmt.Printf("Saved preset %s to %s\n", preset.Name, path)
+	},
+	Example: `
+	roost cluster preset save small --instance-type t3.small --nodes 1 --expiry 2
+	roost cluster preset save ci --instance-type m5.large --nodes 3 --region us-east-1 --email ci@ourco.com
+	roost cluster preset save like-web --from-cluster web --expiry 4
+	`,
+}
+
+// presetFlags sets the fields of the request from the preset save flags. Over the request of a cluster, only the flags
+// which were given are used.
+func presetFlags(cmd *cobra.Command, request cluster.CreateClusterRequest) cluster.CreateClusterRequest {
+	fromCluster := cmd.Flags().Lookup("from-cluster").Changed
+	use := func(flag string) bool {
+		return cmd.Flags().Lookup(flag).Changed || !fromCluster
+	}
+	if use("email") {
+		request.Email, _ = cmd.Flags().GetString("email")
+	}
+	if use("namespace") {
+		request.Namespace, _ = cmd.Flags().GetString("namespace")
+	}
+	if use("ami") {
+		request.Ami, _ = cmd.Flags().GetString("ami")
+	}
+	if use("instance-type") {
+		request.InstanceType, _ = cmd.Flags().GetString("instance-type")
+	}
+	if use("disk-size") {
+		request.DiskSize, _ = cmd.Flags().GetString("disk-size")
+	}
+	if use("region") {
+		request.Region, _ = cmd.Flags().GetString("region")
+	}
+	if use("expiry") {
+		request.ClusterExpiry, _ = cmd.Flags().GetInt("expiry")
+	}
+	if use("k8s") {
+		request.K8sVersion, _ = cmd.Flags().GetString("k8s")
+	}
+	if use("nodes") {
+		request.WorkerNodes, _ = cmd.Flags().GetInt("nodes")
+	}
+	return request
+}
+
+/*
+applyPreset fills the fields of the create request whose flags weren't given from the preset, so explicit flags win
+over the preset and the preset wins over the defaults of the flags.
+*/
+func applyPreset(cmd *cobra.Command, request cluster.CreateClusterRequest, preset cluster.Preset) cluster.CreateClusterRequest {
+	target := reflect.ValueOf(&request).Elem()
+	source := reflect.ValueOf(preset.Request)
+	for field, flag := range createFlags {
+		if value := source.FieldByName(field); !cmd.Flags().Lookup(flag).Changed && !value.IsZero() {
+			target.FieldByName(field).Set(value)
+		}
+	}
+	return request
+}
+
+var clusterPresetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cluster presets",
+	Long:  `A command to list the saved presets and those of the preset_path directories.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		presets, err := cluster.ListPresets()
+		cobra.CheckErr(err)
+		if len(presets) == 0 {
+			fmt.Println("No presets found. Use 'roost cluster preset save' to save one.")
+			return
+		}
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Name", "Instance Type", "Workers", "Region", "K8s", "Disk Size", "Expiry", "Email", "Path"})
+		t.SetStyle(table.StyleDouble)
+		orDash := func(value any) any {
+			if reflect.ValueOf(value).IsZero() {
+				return "-"
+			}
+			return value
+		}
+		for _, preset := range presets {
+			r := preset.Request
+			t.AppendRow(table.Row{preset.Name, orDash(r.InstanceType), orDash(r.WorkerNodes), orDash(r.Region), orDash(r.K8sVersion), orDash(r.DiskSize), orDash(r.ClusterExpiry), orDash(r.Email), preset.Path})
+		}
+		fmt.Print("\n")
+		t.Render()
+		fmt.Print("\n")
+	},
+}
+
+var clusterPresetShowCmd = &cobra.Command{
+	Use:   "show name",
+	Short: "Print a cluster preset",
+	Long:  `A command to print the file of a preset, which can be shared as is.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			cobra.CheckErr(fmt.Errorf("show takes the name of the preset"))
+		}
+		preset, err := cluster.FindPreset(args[0])
+		cobra.CheckErr(err)
+		data, err := os.ReadFile(preset.Path)
+		cobra.CheckErr(err)
+		os.Stdout.Write(data)
+	},
+	Example: `
+	roost cluster preset show ci
+	roost cluster preset show ci > /shared/roost-presets/ci.yaml
+	`,
+}
+
+var clusterPresetDeleteCmd = &cobra.Command{
+	Use:   "delete name",
+	Short: "Delete a saved cluster preset",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			cobra.CheckErr(fmt.Errorf("delete takes the name of the preset"))
+		}
+		path, err := cluster.DeletePreset(args[0])
+		cobra.CheckErr(err)
+		fmt.Printf("Deleted preset %s (%s)\n", args[0], path)
+	},
+}
+
+var clusterDiagnoseCmd = &cobra.Command{
+	Use:   "diagnose alias",
+	Short: "Explain why a Roost cluster failed",
+	Long: `A command to show the failure message and details of a roost cluster, with the parameters it was requested with and
+its timeline, and to suggest fixes for known causes of failures such as missing capacity, exceeded quotas, unavailable
+AMIs or expired credentials. The parameters are known for clusters created from this machine.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			cobra.CheckErr(fmt.Errorf("diagnose takes the alias of one cluster"))
+		}
+		output, _ := cmd.Flags().GetString("output")
+		if output != "text" && output != "json" {
+			cobra.CheckErr(fmt.Errorf("unknown output format %q, use text or json", output))
+		}
+		clusterListData, err := cluster.FetchClusterList(viper.Get("roost_auth_token").(string))
+		cobra.CheckErr(err)
+		clusters, err := cluster.Resolve(clusterListData.Clusters, args, false)
+		cobra.CheckErr(err)
+		records, _ := cluster.LoadRecords()
+		diagnosis := cluster.Diagnose(clusters[0], records)
+
+		if output == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			cobra.CheckErr(encoder.Encode(diagnosis))
+			return
+		}
+		printDiagnosis(diagnosis, time.Now())
+	},
+	Example: `
+	roost cluster diagnose ExampleAlias
+	roost cluster diagnose ExampleAlias -o json
+	`,
+}
+
+// printDiagnosis prints the sections of a diagnosis for people.
+func printDiagnosis(diagnosis cluster.Diagnosis, now time.Time) {
+	clusterData := diagnosis.Cluster
+	fmt.Printf("Cluster %s (ID %d)\n", clusterData.CustomerToken, clusterData.Id)
+	fmt.Printf("  Status: %s\n", cluster.Phase(clusterData))
+
+	fmt.Println("\nTimeline")
+	if len(diagnosis.Timeline) == 0 {
+		fmt.Println("  No times are known")
+	}
+	for _, event := range diagnosis.Timeline {
+		fmt.Printf("  %-10s %s (%s)\n", event.Name, event.Time.Local().Format("2006-01-02 15:04:05 MST"), utils.Ago(event.Time, now))
+	}
+
+	fmt.Println("\nRequest")
+	if request := diagnosis.Request; request != nil {
+		for _, field := range []struct{ name, value string }{
+			{"Email", request.Email},
+			{"Namespace", request.Namespace},
+			{"Region", request.Region},
+			{"Instance type", request.InstanceType},
+			{"Workers", strconv.Itoa(request.WorkerNodes)},
+			{"Disk size", request.DiskSize},
+			{"AMI", request.Ami},
+			{"K8s version", request.K8sVersion},
+			{"Expiry", fmt.Sprintf("%dh", request.ClusterExpiry)},
+		} {
+			fmt.Printf("  %-14s %s\n", field.name, field.value)
+		}
+	} else {
+		fmt.Println("  Not known, the cluster wasn't created from this machine")
+	}
+
+	if clusterData.FailureMsg == "" && clusterData.FailureDetails == "" {
+		fmt.Printf("\nNo failure is reported for cluster %s.\n", clusterData.CustomerToken)
+		return
+	}
+	fmt.Println("\nFailure")
+	if clusterData.FailureMsg != "" {
+		fmt.Println("  " + clusterData.FailureMsg)
+	}
+	if details := clusterData.FailureDetails; details != "" {
+		// Details are often JSON from the cloud provider, which reads better indented.
+		var indented bytes.Buffer
+		if json.Indent(&indented, []byte(details), "", "  ") == nil {
+			details = indented.String()
+		}
+		fmt.Println("  " + strings.ReplaceAll(strings.TrimSpace(details), "\n", "\n  "))
+	}
+
+	fmt.Println("\nHints")
+	if len(diagnosis.Findings) == 0 {
+		fmt.Println("  No known cause matches the failure. Share the failure details above with your Roost admin.")
+	}
+	for _, finding := range diagnosis.Findings {
+		fmt.Printf("  - %s: %s\n", finding.Cause, finding.Fix)
+	}
+}
+
+var clusterPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete stopped, failed and expired Roost clusters",
+	Long: `A command to delete the clusters nobody cleaned up: those stopped for longer than --stopped-older-than, those which
+failed with --failed and those past their expiry with --expired. --email and the selectors narrow the candidates down, and
+clusters labeled with one of --keep-label are never pruned. Stopped clusters the API gives no stop time for are left
+alone, as how long they have been stopped is unknown. The candidates are listed and deleted concurrently after
+confirmation, along with their local kubeconfigs.
+The policy can be kept in a YAML file with the keys stopped_older_than, failed, expired, email, keep_labels, selector
+and field_selector, passed with --policy, so cron can run it unattended with --yes. Flags which are given override it.
+The command exits non-zero when a cluster can't be deleted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		policy := cluster.PrunePolicy{}
+		if path, _ := cmd.Flags().GetString("policy"); path != "" {
+			var err error
+			policy, err = cluster.ReadPrunePolicy(path)
+			cobra.CheckErr(err)
+		}
+		flags := cmd.Flags()
+		if flags.Lookup("stopped-older-than").Changed {
+			policy.StoppedOlderThan, _ = flags.GetString("stopped-older-than")
+		}
+		if flags.Lookup("failed").Changed {
+			policy.Failed, _ = flags.GetBool("failed")
+		}
+		if flags.Lookup("expired").Changed {
+			policy.Expired, _ = flags.GetBool("expired")
+		}
+		if flags.Lookup("email").Changed {
+			policy.Email, _ = flags.GetString("email")
+		}
+		if flags.Lookup("keep-label").Changed {
+			policy.KeepLabels, _ = flags.GetStringSlice("keep-label")
+		}
+		if flags.Lookup("selector").Changed {
+			policy.Selector, _ = flags.GetString("selector")
+		}
+		if flags.Lookup("field-selector").Changed {
+			policy.FieldSelector, _ = flags.GetString("field-selector")
+		}
+
+		authToken := viper.Get("roost_auth_token").(string)
+		clusterListData, err := cluster.FetchClusterList(authToken)
+		cobra.CheckErr(err)
+		records, _ := cluster.LoadRecords()
+		metadata, _ := cluster.LoadMetadata()
+		candidates, err := policy.Candidates(clusterListData.Clusters, records, metadata, time.Now())
+		cobra.CheckErr(err)
+		if len(candidates) == 0 {
+			fmt.Println("No clusters to prune")
+			return
+		}
+
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"ID", "Cluster Alias", "Email", "Status", "Reason"})
+		t.SetStyle(table.StyleDouble)
+		var clusterAliases []string
+		for _, candidate := range candidates {
+			clusterData := candidate.Cluster
+			t.AppendRow(table.Row{clusterData.Id, clusterData.CustomerToken, clusterData.CustomerEmail, cluster.Status(clusterData), candidate.Reason})
+			clusterAliases = append(clusterAliases, clusterData.CustomerToken)
+		}
+		fmt.Printf("%d clusters match the prune policy:\n", len(candidates))
+		t.Render()
+
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			fmt.Println("Dry run, no clusters were deleted")
+			return
+		}
+		yes, _ := cmd.Flags().GetBool("yes")
+		if !utils.ConfirmDestructive(clusterDeleteSummary(clusterAliases, clusterListData.Clusters), fmt.Sprintf("%d clusters", len(clusterAliases)), yes) {
+			fmt.Println("Aborted, no clusters were deleted. Use --yes to prune unattended")
+			return
+		}
+
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		failures := pruneClusters(authToken, clusterAliases, parallel)
+		deleted := len(clusterAliases) - len(failures)
+		fmt.Printf("Pruned %d of %d clusters\n", deleted, len(clusterAliases))
+		if len(failures) > 0 {
+			for _, failure := range failures {
+				fmt.Println("  not deleted:", failure)
+			}
+			os.Exit(1)
+		}
+	},
+	Example: `
+	roost cluster prune --stopped-older-than 24h --failed
+	roost cluster prune --failed --email me --dry-run
+	roost cluster prune --stopped-older-than 7d --expired --keep-label keep=true --yes
+	roost cluster prune --policy prune.yaml --yes
+	# crontab: prune every night at 2am
+	0 2 * * * roost cluster prune --policy /etc/roost/prune.yaml --yes >> /var/log/roost-prune.log 2>&1
 	`,
 }
 
+/*
+pruneClusters deletes the clusters with at most parallel deletes at a time, removing the local kubeconfigs and records
+of the deleted ones. It returns a description of each cluster which couldn't be deleted.
+*/
+func pruneClusters(authToken string, clusterAliases []string, parallel int) []string {
+	if parallel < 1 {
+		parallel = 1
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var deleted, failures []string
+	slots := make(chan struct{}, parallel)
+	for _, clusterAlias := range clusterAliases {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(clusterAlias string) {
+			defer func() { <-slots; wg.Done() }()
+			err := cluster.Delete(authToken, clusterAlias)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fmt.Printf("❌ delete %s: %s\n", clusterAlias, err.Error())
+				failures = append(failures, clusterAlias+": "+err.Error())
+				return
+			}
+			fmt.Printf("✔️ delete %s\n", clusterAlias)
+			deleted = append(deleted, clusterAlias)
+			if _, err := cluster.RemoveLocalKubeconfig(clusterAlias); err != nil {
+				fmt.Println("Unable to remove the kubeconfig of", clusterAlias+":", err.Error())
+			}
+		}(clusterAlias)
+	}
+	wg.Wait()
+	if len(deleted) > 0 {
+		if err := cluster.Forget(deleted...); err != nil {
+			fmt.Println("Unable to forget the records of the deleted clusters:", err.Error())
+		}
+	}
+	sort.Strings(failures)
+	return failures
+}
+
+var clusterCloneCmd = &cobra.Command{
+	Use:   "clone alias",
+	Short: "Launch a copy of a Roost cluster",
+	Long: `A command to launch a new cluster with the parameters of an existing one, such as to reproduce the environment of a
+teammate. Flags which are given override the parameters of the cluster.
+The AMI, instance type, disk size, region, namespace, expiry and k8s version are remembered when a cluster is created
+from this machine. For other clusters, only the email and the number of nodes are known, and the defaults of the flags
+are used for the rest. To clone faithfully, the creator can share them with 'roost cluster preset save --from-cluster'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			cobra.CheckErr(fmt.Errorf("clone takes the alias of one cluster"))
+		}
+		if cmd.Flags().Lookup("kubeconfig").Changed && !cmd.Flags().Lookup("wait").Changed {
+			cobra.CheckErr(fmt.Errorf("--kubeconfig requires --wait"))
+		}
+		clusterListData, err := cluster.FetchClusterList(viper.Get("roost_auth_token").(string))
+		cobra.CheckErr(err)
+		clusters, err := cluster.Resolve(clusterListData.Clusters, args, false)
+		cobra.CheckErr(err)
+		source := clusters[0]
+		records, _ := cluster.LoadRecords()
+		request, missing := cluster.CloneRequest(source, records)
+
+		// Flags which are given override the cluster, the defaults only fill what isn't known about it.
+		isMissing := map[string]bool{}
+		for _, field := range missing {
+			isMissing[field] = true
+		}
+		defaults := cluster.CreateClusterRequest{}
+		defaults.Email, _ = cmd.Flags().GetString("email")
+		defaults.Namespace, _ = cmd.Flags().GetString("namespace")
+		defaults.Ami, _ = cmd.Flags().GetString("ami")
+		defaults.InstanceType, _ = cmd.Flags().GetString("instance-type")
+		defaults.DiskSize, _ = cmd.Flags().GetString("disk-size")
+		defaults.Region, _ = cmd.Flags().GetString("region")
+		defaults.ClusterExpiry, _ = cmd.Flags().GetInt("expiry")
+		defaults.K8sVersion, _ = cmd.Flags().GetString("k8s")
+		defaults.WorkerNodes, _ = cmd.Flags().GetInt("nodes")
+		target := reflect.ValueOf(&request).Elem()
+		from := reflect.ValueOf(defaults)
+		var assumed []string
+		for field, flag := range createFlags {
+			if cmd.Flags().Lookup(flag).Changed || isMissing[field] {
+				target.FieldByName(field).Set(from.FieldByName(field))
+			}
+			if isMissing[field] && !cmd.Flags().Lookup(flag).Changed {
+				assumed = append(assumed, "--"+flag)
+			}
+		}
+		request.Alias, _ = cmd.Flags().GetString("alias")
+		if request.Alias == "" {
+			request.Alias = fmt.Sprintf("%s-clone-%d", source.CustomerToken, time.Now().Unix())
+		}
+		cobra.CheckErr(validateCreateRequest(cmd, request, false))
+
+		fmt.Printf("Cloning cluster %s into %s\n", source.CustomerToken, request.Alias)
+		if len(assumed) > 0 {
+			sort.Strings(assumed)
+			fmt.Printf("Cluster %s wasn't created from this machine, so the defaults of %s are used\n", source.CustomerToken, strings.Join(assumed, ", "))
+		}
+		launchClusters(cmd, []cluster.CreateClusterRequest{request})
+	},
+	Example: `
+	roost cluster clone web --alias web-repro
+	roost cluster clone web --alias web-big --nodes 3 --instance-type m5.large
+	roost cluster clone web --alias web-repro --wait --kubeconfig
+	roost cluster clone web --dry-run
+	`,
+}
 
 func init() {
 	rootCmd.AddCommand(clusterCmd)
 	clusterCmd.AddCommand(clusterCreateCmd)
 	clusterCmd.AddCommand(clusterStopCmd)
+	clusterCmd.AddCommand(clusterStartCmd)
+	clusterCmd.AddCommand(clusterExtendCmd)
 	clusterCmd.AddCommand(clusterKubeconfigCmd)
 	clusterCmd.AddCommand(clusterDeleteCmd)
 	clusterCmd.AddCommand(clusterListCmd)
 	clusterCmd.AddCommand(clusterDetailsCmd)
 	clusterCmd.AddCommand(clusterUICmd)
+	clusterCmd.AddCommand(clusterExecCmd)
+	clusterCmd.AddCommand(clusterShellCmd)
+	clusterCmd.AddCommand(clusterLabelCmd)
+	clusterCmd.AddCommand(clusterAnnotateCmd)
+	clusterCmd.AddCommand(clusterCostCmd)
+	clusterCmd.AddCommand(clusterPresetCmd)
+	clusterCmd.AddCommand(clusterDiagnoseCmd)
+	clusterCmd.AddCommand(clusterPruneCmd)
+	clusterCmd.AddCommand(clusterCloneCmd)
+	clusterPresetCmd.AddCommand(clusterPresetSaveCmd)
+	clusterPresetCmd.AddCommand(clusterPresetListCmd)
+	clusterPresetCmd.AddCommand(clusterPresetShowCmd)
+	clusterPresetCmd.AddCommand(clusterPresetDeleteCmd)
 
-	clusterCreateCmd.Flags().String("email", "", "REQUIRED. Customer email.")
+	addCreateFlags(clusterCreateCmd, "The cluster's")
+	clusterCreateCmd.Flags().Lookup("email").Usage = "REQUIRED. Customer email."
 	clusterCreateCmd.Flags().String("alias", "", "The Alias of the Cluster to be created")
-	clusterCreateCmd.Flags().StringP("namespace", "n", "roostcli", "Namespace in which cluster is to be made (default: roostcli)")
-	clusterCreateCmd.Flags().String("ami", "ubuntu jammy jellyfish 22.04", "Ami to be used in the cluster (default: ubuntu jammy jellyfish 22.04)")
-	clusterCreateCmd.Flags().String("instance-type", "t3.small", "Instance type of the cluster (default: t3.small)")
-	clusterCreateCmd.Flags().String("disk-size", "50GB", "Disk size to use in the cluster (minimum and delfault: 50GB)")
-	clusterCreateCmd.Flags().String("region", "ap-south-1", "Region to create the AWS cluster in (default: ap-south-1)")
-	clusterCreateCmd.Flags().Int("expiry", 1, "The expiry time of the cluster(in hours) (default: 1)")
-	clusterCreateCmd.Flags().String("k8s", "1.22.2", "The k8s version to use in the cluster (default: 1.22.2)")
-	clusterCreateCmd.Flags().Int("nodes", 1, "The number of worker nodes in the cluster (default: 1)")
+	clusterCreateCmd.Flags().Bool("wait", false, "Wait until the cluster is running, exiting non-zero if it fails or the timeout passes")
+	clusterCreateCmd.Flags().Duration("timeout", 15*time.Minute, "How long --wait waits for the cluster to become ready")
+	clusterCreateCmd.Flags().Bool("kubeconfig", false, "Download the kubeconfig once the cluster is ready. Requires --wait")
+	clusterCreateCmd.Flags().StringSliceP("file", "f", nil, "YAML or JSON spec file of one or many clusters, - reads stdin. The other flags are defaults for the clusters in the file")
+	clusterCreateCmd.Flags().Bool("dry-run", false, "Print the requests instead of creating the clusters")
+	clusterCreateCmd.Flags().Bool("override-policy", false, "Launch even if the cluster policy is violated. The override is logged")
+	clusterCreateCmd.Flags().String("preset", "", "Name of a preset, or path of a preset file, to create the cluster from. Flags which are given override it")
+
+	clusterCmd.AddCommand(clusterApplyCmd)
+	clusterApplyCmd.Flags().StringSliceP("file", "f", nil, "REQUIRED. YAML or JSON spec file of the fleet, - reads stdin")
+	clusterApplyCmd.MarkFlagRequired("file")
+	clusterApplyCmd.Flags().String("fleet", "", "Name of the fleet, which scopes --prune (default: the name of the first spec file)")
+	clusterApplyCmd.Flags().String("prune", "", "stop or delete the clusters of the fleet which are no longer declared")
+	clusterApplyCmd.Flags().BoolP("yes", "y", false, "Apply the plan without asking for confirmation")
+	clusterApplyCmd.Flags().Bool("dry-run", false, "Only show the plan")
+	clusterApplyCmd.Flags().Bool("wait", false, "Wait until the created clusters are running, exiting non-zero if one fails")
+	clusterApplyCmd.Flags().Duration("timeout", 15*time.Minute, "How long --wait waits for the clusters to become ready")
+	clusterApplyCmd.Flags().Bool("override-policy", false, "Create the clusters even if the cluster policy is violated. The override is logged")
+	addCreateFlags(clusterApplyCmd, "Default")
 
 	clusterStopCmd.Flags().Int32Slice("id", []int32{}, "Stop Cluster with ID instead of alias. Provide multiple values separated by commas to stop multiple clusters at once.")
-	clusterStopCmd.Flags().StringSlice("alias", []string{}, "Stop Cluster with Alias. Provide multiple values separated by commas to stop multiple clusters at once.")
-	clusterStopCmd.MarkFlagsMutuallyExclusive("id", "alias")
+	clusterStopCmd.Flags().StringSlice("alias", []string{}, "Stop Cluster with Alias. Provide multiple values separated by commas to stop multiple clusters at once. Accepts IDs, aliases, unique prefixes and globs such as 'ci-*'.")
+	clusterStopCmd.Flags().BoolP("yes", "y", false, "Stop the clusters matched by a selector, a glob, a prefix or several aliases without asking for confirmation")
+	addSelectorFlags(clusterStopCmd)
+	clusterStopCmd.MarkFlagsMutuallyExclusive("id", "alias", "selector")
+	clusterStopCmd.MarkFlagsMutuallyExclusive("id", "alias", "field-selector")
+
+	clusterStartCmd.Flags().Int32Slice("id", []int32{}, "Start the clusters with these IDs")
+	clusterStartCmd.Flags().StringSlice("alias", []string{}, "Start the clusters with these aliases. Accepts IDs, aliases, unique prefixes and globs such as 'ci-*'.")
+	clusterStartCmd.Flags().BoolP("yes", "y", false, "Start the clusters matched by a selector, a glob, a prefix or several aliases without asking for confirmation")
+	clusterStartCmd.Flags().Bool("wait", false, "Wait until the clusters are running, exiting non-zero if one fails")
+	clusterStartCmd.Flags().Duration("timeout", 15*time.Minute, "How long --wait waits for the clusters to become ready")
+	addSelectorFlags(clusterStartCmd)
+	clusterStartCmd.MarkFlagsMutuallyExclusive("id", "alias", "selector")
+	clusterStartCmd.MarkFlagsMutuallyExclusive("id", "alias", "field-selector")
+
+	clusterExtendCmd.Flags().String("by", "", "REQUIRED. How much longer the clusters should live, e.g. 2h or 1d. Rounded up to whole hours")
+	clusterExtendCmd.MarkFlagRequired("by")
+	clusterExtendCmd.Flags().Int32Slice("id", []int32{}, "Extend the clusters with these IDs")
+	clusterExtendCmd.Flags().StringSlice("alias", []string{}, "Extend the clusters with these aliases. Accepts IDs, aliases, unique prefixes and globs such as 'ci-*'.")
+	clusterExtendCmd.Flags().BoolP("yes", "y", false, "Extend the clusters matched by a selector, a glob, a prefix or several aliases without asking for confirmation")
+	addSelectorFlags(clusterExtendCmd)
+	clusterExtendCmd.MarkFlagsMutuallyExclusive("id", "alias", "selector")
+	clusterExtendCmd.MarkFlagsMutuallyExclusive("id", "alias", "field-selector")
 
 	clusterDeleteCmd.Flags().Int32Slice("id", []int32{}, "Delete Cluster with ID instead of alias. Provide multiple values separated by commas to delete multiple clusters at once.")
-	clusterDeleteCmd.Flags().StringSlice("alias", []string{}, "Delete Cluster with Alias. Provide multiple values separated by commas to delete multiple clusters at once.")
-	clusterDeleteCmd.MarkFlagsMutuallyExclusive("id", "alias")
+	clusterDeleteCmd.Flags().StringSlice("alias", []string{}, "Delete Cluster with Alias. Provide multiple values separated by commas to delete multiple clusters at once. Accepts IDs, aliases, unique prefixes and globs such as 'ci-*'.")
+	addSelectorFlags(clusterDeleteCmd)
+	clusterDeleteCmd.MarkFlagsMutuallyExclusive("id", "alias", "selector")
+	clusterDeleteCmd.MarkFlagsMutuallyExclusive("id", "alias", "field-selector")
+	clusterDeleteCmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation")
+	clusterDeleteCmd.Flags().Bool("dry-run", false, "Print the API calls which would be made without deleting anything")
 
 	clusterListCmd.Flags().Bool("running", false, "Get all running clusters")
 	clusterListCmd.Flags().Bool("stopped", false, "Get all stopped clusters")
+	clusterListCmd.Flags().StringSlice("status", nil, "Only list clusters in these states: "+strings.Join(cluster.Statuses, ", "))
+	clusterListCmd.Flags().StringSlice("type", nil, "Only list clusters of these cluster types, e.g. roost or managed")
+	clusterListCmd.Flags().StringSlice("env", nil, "Only list clusters of these env types")
+	clusterListCmd.Flags().String("email", "", "Only list clusters of this customer email, globs such as '*@ourco.com' are accepted")
+	clusterListCmd.Flags().String("name", "", "Only list clusters whose alias matches this glob, e.g. 'ci-*'")
+	clusterListCmd.Flags().String("created-before", "", "Only list clusters created before this time: a duration ago such as 2d, or a date such as '2023-05-01 14:00' in the local time zone")
+	clusterListCmd.Flags().String("created-after", "", "Only list clusters created after this time, see --created-before")
+	clusterListCmd.Flags().String("sort-by", "", "Sort the clusters by a column: "+strings.Join(cluster.SortColumns(), ", "))
+	clusterListCmd.Flags().Bool("reverse", false, "Reverse the order of --sort-by")
+	clusterListCmd.Flags().Int("limit", 0, "List at most this many clusters, after sorting")
+	clusterListCmd.Flags().Bool("absolute-times", false, "Show times in the local time zone instead of relative to now")
+	addSelectorFlags(clusterListCmd)
+	clusterListCmd.Flags().StringP("output", "o", "table", "Output format: table, wide (with the type, env, running, stopped and failure columns) or json")
+	clusterListCmd.Flags().Bool("watch", false, "Keep polling and print a JSON line for every cluster which is added, modified or deleted. Requires -o json")
+	clusterListCmd.Flags().Duration("interval", 10*time.Second, "How often --watch polls the cluster list")
+
+	clusterCmd.AddCommand(clusterWatchCmd)
+	clusterWatchCmd.Flags().Duration("interval", 10*time.Second, "How often the dashboard refreshes the cluster list")
+	addSelectorFlags(clusterWatchCmd)
 
 	clusterKubeconfigCmd.Flags().Int32Slice("id", []int32{}, "Get kubeConfig of a cluster with ID. Provide multiple values separated by commas to get kubeconfig of multiple clusters at once.")
-	clusterKubeconfigCmd.Flags().StringSlice("alias", []string{}, "Get kubeConfig of a cluster with Alias. Provide multiple values separated by commas to get kubeconfig of multiple clusters at once.")
-	clusterKubeconfigCmd.MarkFlagsMutuallyExclusive("id", "alias")
+	clusterKubeconfigCmd.Flags().StringSlice("alias", []string{}, "Get kubeConfig of a cluster with Alias. Provide multiple values separated by commas to get kubeconfig of multiple clusters at once. Accepts IDs, aliases, unique prefixes and globs such as 'ci-*'.")
+	clusterKubeconfigCmd.Flags().Bool("merge", false, "Merge the kubeconfig into ~/.kube/config (or the first file in $KUBECONFIG) as context roost-cluster-<alias> instead of writing a separate file")
+	clusterKubeconfigCmd.Flags().Bool("switch", false, "Make the merged context the current context. Requires --merge")
+	clusterKubeconfigCmd.Flags().Bool("verify", false, "Check the API server of the kubeconfig answers /version and report when its certificates expire")
+	clusterKubeconfigCmd.Flags().Duration("timeout", 10*time.Second, "How long --verify waits for the API server")
+	addSelectorFlags(clusterKubeconfigCmd)
+	clusterKubeconfigCmd.MarkFlagsMutuallyExclusive("id", "alias", "selector")
+	clusterKubeconfigCmd.MarkFlagsMutuallyExclusive("id", "alias", "field-selector")
 
 	clusterDetailsCmd.Flags().Int32("id", -1, "Get the details of a cluster with ID")
-	clusterDetailsCmd.Flags().String("alias", "", "Get the details of a cluster with Alias")
+	clusterDetailsCmd.Flags().String("alias", "", "Get the details of a cluster with Alias, ID or a unique prefix of either")
 	clusterDetailsCmd.Flags().StringP("output", "o", "", "Specify the output path to get the JSON of cluster Details")
 	clusterDetailsCmd.MarkFlagsMutuallyExclusive("id", "alias")
 
 	clusterUICmd.Flags().Int32("id", -1, "open the UI of a cluster by using it's ID.")
-	clusterUICmd.Flags().String("alias", "", "open the UI of a cluster by using its Alias.")
+	clusterUICmd.Flags().String("alias", "", "open the UI of a cluster by using its Alias, ID or a unique prefix of either.")
 	clusterUICmd.MarkFlagsMutuallyExclusive("id", "alias")
+	clusterUICmd.Flags().String("scheme", "", "Scheme of the UI (default: the ui_scheme setting, or http)")
+	clusterUICmd.Flags().Int("port", 0, "Port of the UI on the cluster (default: the ui_port setting, or 30070)")
+	clusterUICmd.Flags().String("path", "", "Path of the UI (default: the ui_path setting, or /app)")
+	clusterUICmd.Flags().Bool("print-url", false, "Only print the URL of the UI")
+	clusterUICmd.Flags().Bool("no-browser", false, "Print the URL of the UI instead of opening a browser")
+	clusterUICmd.Flags().String("browser", "", "Command to open the UI with, %s is replaced by the URL (default: the browser setting, or $BROWSER)")
+	clusterUICmd.Flags().Bool("no-check", false, "Open the UI without checking that it is reachable")
+	clusterUICmd.Flags().Bool("port-forward", false, "Reach the UI through kubectl port-forward, for clusters whose NodePort is firewalled")
+	clusterUICmd.Flags().Int("local-port", 0, "Local port of --port-forward (default: a free port)")
+	clusterUICmd.Flags().Duration("timeout", 5*time.Second, "How long to wait for the UI, or the port-forward, to accept connections")
+	clusterUICmd.MarkFlagsMutuallyExclusive("print-url", "port-forward")
+
+	clusterExecCmd.Flags().StringP("namespace", "n", "", "Namespace of the context (default: the namespace the cluster was created with)")
+	clusterExecCmd.Flags().Bool("all-running", false, "Run the command against every running cluster, prefixing its output with the alias of the cluster")
+
+	clusterShellCmd.Flags().StringP("namespace", "n", "", "Namespace of the context (default: the namespace the cluster was created with)")
+
+	clusterLabelCmd.Flags().Bool("overwrite", false, "Change the value of labels which are already set")
+	clusterAnnotateCmd.Flags().Bool("overwrite", false, "Change the value of annotations which are already set")
+
+	clusterCostCmd.Flags().StringSlice("by", []string{cluster.CostByEmail}, "Columns to group the costs by: "+strings.Join(cluster.CostGroupings, ", "))
+	clusterCostCmd.Flags().String("period", "month", "Length of the periods of --by period: "+strings.Join(cluster.CostPeriods, ", "))
+	clusterCostCmd.Flags().String("since", "", "Only count the cost from this time: a duration ago such as 30d, or a date such as '2023-05-01' in the local time zone")
+	clusterCostCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	clusterCostCmd.Flags().Bool("print-pricing", false, "Print the pricing table in use as JSON, to start a pricing file from")
+	addSelectorFlags(clusterCostCmd)
+
+	clusterDiagnoseCmd.Flags().StringP("output", "o", "text", "Output format: text or json")
+
+	clusterPruneCmd.Flags().String("policy", "", "YAML file of the prune policy, see the description")
+	clusterPruneCmd.Flags().String("stopped-older-than", "", "Prune clusters stopped for longer than this, e.g. 24h or 7d")
+	clusterPruneCmd.Flags().Bool("failed", false, "Prune failed clusters")
+	clusterPruneCmd.Flags().Bool("expired", false, "Prune running clusters past the expiry recorded when they were created from this machine")
+	clusterPruneCmd.Flags().String("email", "", "Only prune clusters of this customer email, a glob such as '*@ourco.com', or me for the email setting")
+	clusterPruneCmd.Flags().StringSlice("keep-label", nil, "Never prune clusters with this label, key=value or a key with any value")
+	addSelectorFlags(clusterPruneCmd)
+	clusterPruneCmd.Flags().BoolP("yes", "y", false, "Prune without asking for confirmation")
+	clusterPruneCmd.Flags().Bool("dry-run", false, "Only list the clusters which would be pruned")
+	clusterPruneCmd.Flags().Int("parallel", 4, "How many clusters to delete at a time")
+
+	clusterCloneCmd.Flags().String("alias", "", "The alias of the clone, by default the alias of the cluster followed by -clone and a timestamp")
+	addCreateFlags(clusterCloneCmd, "The clone's")
+	clusterCloneCmd.Flags().Lookup("email").Usage = "Customer email of the clone, by default that of the cluster"
+	clusterCloneCmd.Flags().Bool("wait", false, "Wait until the clone is running, exiting non-zero if it fails or the timeout passes")
+	clusterCloneCmd.Flags().Duration("timeout", 15*time.Minute, "How long --wait waits for the clone to become ready")
+	clusterCloneCmd.Flags().Bool("kubeconfig", false, "Download the kubeconfig once the clone is ready. Requires --wait")
+	clusterCloneCmd.Flags().Bool("dry-run", false, "Print the request instead of creating the clone")
+	clusterCloneCmd.Flags().Bool("override-policy", false, "Launch the clone even if the cluster policy is violated. The override is logged")
+
+	clusterPresetSaveCmd.Flags().String("from-cluster", "", "Save the parameters the cluster was created with from this machine. Accepts IDs, aliases and unique prefixes.")
+	clusterPresetSaveCmd.Flags().Bool("overwrite", false, "Replace an existing preset")
+	addCreateFlags(clusterPresetSaveCmd, "The preset's")
 
 }
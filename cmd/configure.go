@@ -53,7 +53,10 @@ var configureCmd = &cobra.Command{
 		userinput.AuthToken = roostTokenPromptContent
 		userinput.EntServer = roostServerPromptContent
 		//userinput.JwtToken = roostJWTPromptContent
-		utils.AcceptFromPrompt(&userinput)
+		err := utils.AcceptFromPrompt(&userinput)
+		if err != nil {
+			cobra.CheckErr(fmt.Errorf("configure prompt error %q", err.Error()))
+		}
 
 		cfginput.AuthToken = userinput.AuthToken
 		cfginput.EntServer = userinput.EntServer
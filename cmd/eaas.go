@@ -68,29 +68,29 @@ var eaasTriggerCmd = &cobra.Command{
 		isSetName := cmd.Flags().Lookup("name").Changed
 		if isSetName {
 			AppName, _ := cmd.Flags().GetString("name")
-			for _, AppData := range getapplist.Data {
-				if AppData.Appname == AppName {
-					eaasObj := eaas.TriggerEAASObj{}
-
-					eaasObj.Branch = AppData.AppRepoBranch
-					eaasObj.Type = AppData.CodeRepo
-					eaasObj.UserName = AppData.CreatedBy
-
-					repo := strings.Split(AppData.AppRepoName, "/")
-					eaasObj.OwnerName = repo[0]
-					var repoName string 
-					for i := 1; i < len(repo); i++ {
-						repoName += repo[i]
-					}
-					eaasObj.RepoName = repoName
-					year, month, day := time.Now().Date()
-					hour := time.Now().Local().Hour()
-					min := time.Now().Local().Minute()
+			apps, err := eaas.ResolveApps(AppName, getapplist.Data, false)
+			cobra.CheckErr(err)
+			for _, AppData := range apps {
+				eaasObj := eaas.TriggerEAASObj{}
+
+				eaasObj.Branch = AppData.AppRepoBranch
+				eaasObj.Type = AppData.CodeRepo
+				eaasObj.UserName = AppData.CreatedBy
+
+				repo := strings.Split(AppData.AppRepoName, "/")
+				eaasObj.OwnerName = repo[0]
+				var repoName string 
+				for i := 1; i < len(repo); i++ {
+					repoName += repo[i]
+				}
+				eaasObj.RepoName = repoName
+				year, month, day := time.Now().Date()
+				hour := time.Now().Local().Hour()
+				min := time.Now().Local().Minute()
 
-					eaasObj.Title = "trigger-" + fmt.Sprintf("%d%d%d-%d%d", year, month, day, hour, min) 
+				eaasObj.Title = "trigger-" + fmt.Sprintf("%d%d%d-%d%d", year, month, day, hour, min) 
 
-					trigger(eaasObj, "zbio", AppData.ID)
-				}
+				trigger(eaasObj, "zbio", AppData.ID)
 			}
 		} else {
 			if getapplist.Count < 1 {
@@ -322,13 +322,20 @@ var eaasDeleteCmd = &cobra.Command{
 	Long: ``,
 	Run: func(cmd *cobra.Command, args []string) {
 		eaasObj := eaas.DeleteAppObj{}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+		keepWorkflows, _ := cmd.Flags().GetBool("keep-workflows")
+		apiEndPoint := "/api/application/client/git/token/delete"
 		deleteApp := func(ID string) {
 			eaasObj.AppID = "zbio"
 			eaasObj.GitTokenID = ID
-			eaasObj.DeleteAssociatedWorkFlows = true 
+			eaasObj.DeleteAssociatedWorkFlows = !keepWorkflows
+			if dryRun {
+				utils.PrintDryRun(http.MethodPost, apiEndPoint, eaasObj)
+				return
+			}
 			spinner := spinner.NewSpinner()
 			spinner.Start("Deleting the requested EAAS application")
-			apiEndPoint := "/api/application/client/git/token/delete"
 			reqBuff, err := json.Marshal(eaasObj)
 			if err != nil {
 				spinner.Stop(false)
@@ -352,14 +359,13 @@ var eaasDeleteCmd = &cobra.Command{
 		}
 		getapplist := eaas.GetEaasList(false)
 
+		var selectedApps []eaas.Eaaslistdata
 		isSetName := cmd.Flags().Lookup("name").Changed
 		if isSetName {
 			AppName, _ := cmd.Flags().GetString("name")
-			for _, AppData := range getapplist.Data {
-				if AppData.Appname == AppName {
-					deleteApp(AppData.ID)
-				}
-			}
+			var err error
+			selectedApps, err = eaas.ResolveApps(AppName, getapplist.Data, true)
+			cobra.CheckErr(err)
 		} else {
 			if getapplist.Count < 1 {
 				fmt.Println("No applications found.")
@@ -370,15 +376,42 @@ var eaasDeleteCmd = &cobra.Command{
 				Apps = append(Apps, AppData.Appname)
 			}
 
-			AppNameInput := utils.PromptSelectInput(Apps, "Select the application you want to delete.")
-			if AppNameInput != "" {
+			AppNameInputs := utils.PromptMultiSelectInput(Apps, "Select the applications you want to delete.")
+			for _, AppNameInput := range AppNameInputs {
 				for _, AppData := range getapplist.Data {
 					if AppData.Appname == AppNameInput {
-						deleteApp(AppData.ID)
+						selectedApps = append(selectedApps, AppData)
 					}
 				}
 			}
 		}
+		if len(selectedApps) == 0 {
+			return
+		}
+
+		if !dryRun {
+			var summary []string
+			for _, AppData := range selectedApps {
+				summary = append(summary, fmt.Sprintf("application %s (%s@%s)", AppData.Appname, AppData.AppRepoName, AppData.AppRepoBranch))
+				if !keepWorkflows {
+					workflows := eaas.GetWorkFlowIDs(eaas.GetWorkFlowIDReq{AppID: "zbio", GitTokenID: AppData.ID})
+					if len(workflows) > 0 {
+						summary = append(summary, fmt.Sprintf("%d associated workflows: %s", len(workflows), strings.Join(workflows, ", ")))
+					}
+				}
+			}
+			confirmName := selectedApps[0].Appname
+			if len(selectedApps) > 1 {
+				confirmName = fmt.Sprintf("%d applications", len(selectedApps))
+			}
+			if !utils.ConfirmDestructive(summary, confirmName, yes) {
+				fmt.Println("Aborted, no applications were deleted")
+				return
+			}
+		}
+		for _, AppData := range selectedApps {
+			deleteApp(AppData.ID)
+		}
 	},
 }
 
@@ -399,7 +432,7 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// eaasCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-	eaasTriggerCmd.Flags().StringP("name", "n", "", "Trigger an EAAS workflow by application name.")
+	eaasTriggerCmd.Flags().StringP("name", "n", "", "Trigger an EAAS workflow by application name, ID or a unique prefix of either.")
 
 	eaasLogsCmd.Flags().Int("take", 15, "Set how many environments will be fetched.")
 
@@ -409,5 +442,8 @@ func init() {
 
 	eaasEnvDetailsCmd.Flags().Int("take", 10, "Set how many environments will be fetched.")
 
-	eaasDeleteCmd.Flags().StringP("name", "n", "", "Delete an application by it's name.")
+	eaasDeleteCmd.Flags().StringP("name", "n", "", "Delete applications by name, ID, unique prefix or a glob such as 'web-*'.")
+	eaasDeleteCmd.Flags().Bool("keep-workflows", false, "Keep the workflows associated with the application.")
+	eaasDeleteCmd.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation.")
+	eaasDeleteCmd.Flags().Bool("dry-run", false, "Print the API calls which would be made without deleting anything.")
 }
\ No newline at end of file
@@ -0,0 +1,441 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/cluster"
+	"github.com/ZB-io/internal/roostcli/pkg/config"
+	"github.com/ZB-io/internal/roostcli/pkg/kubeconfig"
+	"github.com/ZB-io/internal/roostcli/pkg/spinner"
+	"github.com/ZB-io/internal/roostcli/pkg/team"
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var kubeconfigCmd = &cobra.Command{
+	Use:   "kubeconfig",
+	Short: "A command to manage the kubeconfigs downloaded by roost",
+	Long: `A command to manage the kubeconfigs of roost clusters and team clusters on this machine, both the files in
+'$HOME/.kube/roostconfig' and '$HOME/.kube/roostteamconfig' and the contexts merged into the default kubeconfig.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(config.LoadServerFromViper())
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			cmd.Help()
+			return
+		}
+		if args[0] != "help" {
+			fmt.Printf("%v is not a valid command\n", args[0])
+		}
+		cmd.Help()
+	},
+	Example: `
+	roost kubeconfig list
+	roost kubeconfig use --alias ExampleAlias
+	roost kubeconfig prune
+	roost kubeconfig refresh
+	`,
+}
+
+var kubeconfigListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the kubeconfigs downloaded by roost",
+	Long:  `A command to list the kubeconfigs downloaded by roost with the state of their cluster and whether their API server can be reached.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		locals, _ := localKubeconfigs()
+		if len(locals) == 0 {
+			fmt.Println("No kubeconfigs are found")
+			return
+		}
+		reachable := probeServers(locals, timeout)
+
+		current := currentKubeconfig(locals)
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"", "Kind", "Name", "Location", "Server", "State", "Reachable"})
+		t.SetStyle(table.StyleDouble)
+		for i, local := range locals {
+			marker := ""
+			if local.Location() == current {
+				marker = "*"
+			}
+			t.AppendRow(table.Row{marker, local.Kind, local.Name, local.Location(), local.Server, local.State, reachable[i]})
+		}
+		fmt.Print("\n")
+		t.Render()
+		fmt.Print("\n")
+	},
+	Example: `
+	roost kubeconfig list
+	roost kubeconfig list --timeout 1s
+	`,
+}
+
+var kubeconfigUseCmd = &cobra.Command{
+	Use:   "use",
+	Short: "Switch to a kubeconfig downloaded by roost",
+	Long: `A command to make kubectl use the kubeconfig of a roost cluster or team cluster. A context merged into the default
+kubeconfig becomes its current context, a downloaded file is merged into it first. With --export the command instead
+prints the export of KUBECONFIG for the downloaded file, to be evaluated by the shell.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		alias, _ := cmd.Flags().GetString("alias")
+		teamName, _ := cmd.Flags().GetString("team")
+		export, _ := cmd.Flags().GetBool("export")
+
+		locals, err := cluster.FindLocalKubeconfigs()
+		cobra.CheckErr(err)
+		kind, name := cluster.KubeconfigCluster, alias
+		if teamName != "" {
+			kind, name = cluster.KubeconfigTeam, teamName
+		}
+		if name == "" {
+			var options []string
+			choices := map[string]cluster.LocalKubeconfig{}
+			for _, local := range locals {
+				option := local.Kind + " " + local.Name
+				if _, ok := choices[option]; !ok {
+					options = append(options, option)
+					choices[option] = local
+				}
+			}
+			if len(options) == 0 {
+				fmt.Println("No kubeconfigs are found, use 'roost cluster get-kubeconfig' to download one")
+				return
+			}
+			choice, ok := choices[utils.PromptSelectInput(options, "Select the kubeconfig to use")]
+			if !ok {
+				fmt.Println("Please select an option")
+				return
+			}
+			kind, name = choice.Kind, choice.Name
+		}
+
+		var file, context *cluster.LocalKubeconfig
+		for i, local := range locals {
+			if local.Kind != kind || local.Name != name {
+				continue
+			}
+			if local.Context != "" {
+				context = &locals[i]
+			} else {
+				file = &locals[i]
+			}
+		}
+		if file == nil && context == nil {
+			cobra.CheckErr(fmt.Errorf("no kubeconfig of %s %s is found, use 'roost kubeconfig list' to see them", kind, name))
+		}
+
+		if export {
+			if file == nil {
+				cobra.CheckErr(fmt.Errorf("%s %s has no downloaded kubeconfig, only %s", kind, name, context.Location()))
+			}
+			fmt.Printf("export KUBECONFIG=%s\n", file.Path)
+			return
+		}
+		if context != nil {
+			cobra.CheckErr(kubeconfig.UseContext(context.Path, context.Context))
+			fmt.Printf("Switched to context %s in %s.\n", context.Context, context.Path)
+			return
+		}
+		data, err := os.ReadFile(file.Path)
+		cobra.CheckErr(err)
+		defaultPath, err := kubeconfig.DefaultPath()
+		cobra.CheckErr(err)
+		contextName := cluster.ContextName(name)
+		if kind == cluster.KubeconfigTeam {
+			contextName = team.ContextName(name)
+		}
+		cobra.CheckErr(kubeconfig.MergeInto(defaultPath, contextName, data, true))
+		fmt.Printf("Merged %s into %s as context %s, which is now the current context.\n", file.Path, defaultPath, contextName)
+	},
+	Example: `
+	roost kubeconfig use
+	roost kubeconfig use --alias ExampleAlias
+	roost kubeconfig use --team ExampleTeam
+	eval $(roost kubeconfig use --alias ExampleAlias --export)
+	`,
+}
+
+var kubeconfigPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove the kubeconfigs of clusters which are gone, stopped or expired",
+	Long: `A command to remove the downloaded kubeconfigs and merged contexts of clusters which were deleted, are stopped or
+have expired, and of teams you are no longer part of.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		var stale []cluster.LocalKubeconfig
+		var summary []string
+		locals, _ := localKubeconfigs()
+		for _, local := range locals {
+			if local.Stale() {
+				stale = append(stale, local)
+				summary = append(summary, fmt.Sprintf("%s (%s %s, %s)", local.Location(), local.Kind, local.Name, local.State))
+			}
+		}
+		if len(stale) == 0 {
+			fmt.Println("No kubeconfigs to prune")
+			return
+		}
+		if dryRun {
+			for _, line := range summary {
+				fmt.Println("[dry-run] remove", line)
+			}
+			return
+		}
+		if !utils.ConfirmDestructive(summary, "", yes) {
+			fmt.Println("Aborted")
+			return
+		}
+
+		failed := false
+		for _, local := range stale {
+			if err := cluster.RemoveLocal(local); err != nil {
+				fmt.Println("Unable to remove", local.Location()+":", err.Error())
+				failed = true
+				continue
+			}
+			fmt.Println("Removed", local.Location())
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+	Example: `
+	roost kubeconfig prune --dry-run
+	roost kubeconfig prune --yes
+	`,
+}
+
+var kubeconfigRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Download the kubeconfigs of roost clusters again",
+	Long: `A command to download the kubeconfigs of roost clusters and team clusters again, updating the downloaded files and the
+merged contexts in place. Kubeconfigs of clusters which are gone are skipped, use 'roost kubeconfig prune' to remove them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		aliases, _ := cmd.Flags().GetStringSlice("alias")
+		teamNames, _ := cmd.Flags().GetStringSlice("team")
+		wanted := map[string]bool{}
+		for _, alias := range aliases {
+			wanted[cluster.KubeconfigCluster+" "+alias] = true
+		}
+		for _, teamName := range teamNames {
+			wanted[cluster.KubeconfigTeam+" "+teamName] = true
+		}
+
+		locals, teams := localKubeconfigs()
+		authToken := viper.Get("roost_auth_token").(string)
+		fetched := map[string]string{}
+		failed := false
+		for _, local := range locals {
+			key := local.Kind + " " + local.Name
+			if len(wanted) > 0 && !wanted[key] {
+				continue
+			}
+			if local.State == cluster.StateGone {
+				fmt.Printf("Skipping %s, the %s is gone\n", local.Location(), local.Kind)
+				continue
+			}
+			data, ok := fetched[key]
+			if !ok {
+				var err error
+				data, err = fetchKubeconfig(authToken, local, teams)
+				if err != nil {
+					fmt.Println("Unable to refresh", local.Location()+":", err.Error())
+					failed = true
+					continue
+				}
+				fetched[key] = data
+			}
+			var err error
+			if local.Context != "" {
+				err = kubeconfig.MergeInto(local.Path, local.Context, []byte(data), false)
+			} else {
+				_, err = kubeconfig.ParseValid([]byte(data))
+				if err == nil {
+					err = kubeconfig.WriteFile(local.Path, []byte(data))
+				}
+			}
+			if err != nil {
+				fmt.Println("Unable to refresh", local.Location()+":", err.Error())
+				failed = true
+				continue
+			}
+			fmt.Println("Refreshed", local.Location())
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+	Example: `
+	roost kubeconfig refresh
+	roost kubeconfig refresh --alias ExampleAlias
+	roost kubeconfig refresh --team ExampleTeam
+	`,
+}
+
+// localKubeconfigs finds the local kubeconfigs and sets the state of their clusters and teams. It also returns the teams
+// of the user when there are team kubeconfigs.
+func localKubeconfigs() ([]cluster.LocalKubeconfig, []team.TeamList) {
+	locals, err := cluster.FindLocalKubeconfigs()
+	cobra.CheckErr(err)
+	if len(locals) == 0 {
+		return locals, nil
+	}
+
+	spinner := spinner.NewSpinner()
+	spinner.Start("Fetching the state of the clusters")
+	clusterListData, err := cluster.FetchClusterList(viper.Get("roost_auth_token").(string))
+	if err != nil {
+		spinner.Stop(false)
+		cobra.CheckErr(err)
+	}
+	records, _ := cluster.LoadRecords()
+	cluster.SetClusterStates(locals, clusterListData.Clusters, records, time.Now())
+
+	hasTeams := false
+	for _, local := range locals {
+		hasTeams = hasTeams || local.Kind == cluster.KubeconfigTeam
+	}
+	var teams team.TeamListResponse
+	if hasTeams {
+		teams, err = fetchTeams()
+		if err != nil {
+			spinner.Stop(false)
+			cobra.CheckErr(err)
+		}
+		for i, local := range locals {
+			if local.Kind != cluster.KubeconfigTeam {
+				continue
+			}
+			// The state of the cluster attached to a team isn't known, only whether the team still is.
+			if _, found := findTeam(teams.Teamlist, local.Name); !found {
+				locals[i].State = cluster.StateGone
+			}
+		}
+	}
+	spinner.Stop(true)
+	return locals, teams.Teamlist
+}
+
+// findTeam finds a team by the name its kubeconfig was stored or merged under.
+func findTeam(teams []team.TeamList, name string) (team.TeamList, bool) {
+	for _, teamData := range teams {
+		if team.ContextName(teamData.Name) == team.ContextName(name) {
+			return teamData, true
+		}
+	}
+	return team.TeamList{}, false
+}
+
+// fetchKubeconfig downloads the kubeconfig a local kubeconfig was made from.
+func fetchKubeconfig(authToken string, local cluster.LocalKubeconfig, teams []team.TeamList) (string, error) {
+	if local.Kind == cluster.KubeconfigCluster {
+		kubeconfig, err := cluster.GetKubeconfig(authToken, local.Name)
+		return kubeconfig.Kubeconfig, err
+	}
+	teamData, found := findTeam(teams, local.Name)
+	if !found {
+		return "", fmt.Errorf("the team %s is not found", local.Name)
+	}
+	return fetchTeamKubeconfig(team.TeamKubeConfigObj{TeamId: teamData.TeamId, Clustertype: []string{"roost", "managed"}})
+}
+
+// probeServers checks the API servers of the kubeconfigs concurrently and describes the result of each.
+func probeServers(locals []cluster.LocalKubeconfig, timeout time.Duration) []string {
+	reachable := make([]string, len(locals))
+	var wg sync.WaitGroup
+	for i, local := range locals {
+		if local.Server == "" {
+			reachable[i] = "-"
+			continue
+		}
+		wg.Add(1)
+		go func(i int, server string) {
+			defer wg.Done()
+			if err := kubeconfig.Reachable(server, timeout); err != nil {
+				reachable[i] = "no"
+				return
+			}
+			reachable[i] = "yes"
+		}(i, local.Server)
+	}
+	wg.Wait()
+	return reachable
+}
+
+// currentKubeconfig returns the Location of the kubeconfig kubectl uses: a downloaded file $KUBECONFIG points to, or the
+// current context of the default kubeconfig.
+func currentKubeconfig(locals []cluster.LocalKubeconfig) string {
+	defaultPath, err := kubeconfig.DefaultPath()
+	if err != nil {
+		return ""
+	}
+	for _, local := range locals {
+		if local.Context == "" && local.Path == defaultPath {
+			return local.Path
+		}
+	}
+	config, err := kubeconfig.Load(defaultPath)
+	if err != nil || config.CurrentContext == "" {
+		return ""
+	}
+	return cluster.LocalKubeconfig{Path: defaultPath, Context: config.CurrentContext}.Location()
+}
+
+func init() {
+	rootCmd.AddCommand(kubeconfigCmd)
+	kubeconfigCmd.AddCommand(kubeconfigListCmd)
+	kubeconfigCmd.AddCommand(kubeconfigUseCmd)
+	kubeconfigCmd.AddCommand(kubeconfigPruneCmd)
+	kubeconfigCmd.AddCommand(kubeconfigRefreshCmd)
+
+	kubeconfigListCmd.Flags().Duration("timeout", 3*time.Second, "How long to wait for an API server to accept a connection")
+
+	kubeconfigUseCmd.Flags().String("alias", "", "Alias of the cluster whose kubeconfig to use")
+	kubeconfigUseCmd.Flags().String("team", "", "Name of the team whose cluster kubeconfig to use")
+	kubeconfigUseCmd.Flags().Bool("export", false, "Print the export of KUBECONFIG for the downloaded file instead of switching the current context")
+	kubeconfigUseCmd.MarkFlagsMutuallyExclusive("alias", "team")
+
+	kubeconfigPruneCmd.Flags().Bool("dry-run", false, "Print the kubeconfigs which would be removed without removing them")
+	kubeconfigPruneCmd.Flags().BoolP("yes", "y", false, "Remove without asking for confirmation")
+
+	kubeconfigRefreshCmd.Flags().StringSlice("alias", nil, "Only refresh the kubeconfigs of these clusters")
+	kubeconfigRefreshCmd.Flags().StringSlice("team", nil, "Only refresh the kubeconfigs of these teams")
+}
@@ -39,6 +39,8 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.roost/config)")
+	rootCmd.PersistentFlags().Bool("confirm-name", false, "Ask to type the name of what is deleted instead of answering y (default: the require_typed_confirmation setting)")
+	cobra.CheckErr(viper.BindPFlag("require_typed_confirmation", rootCmd.PersistentFlags().Lookup("confirm-name")))
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -0,0 +1,462 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/cluster"
+	"github.com/ZB-io/internal/roostcli/pkg/config"
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// scheduleGrace is how late 'roost schedule run' may execute a firing, such as after the machine slept. Later firings
+// are recorded as missed, so a stop due last night doesn't stop the clusters started this morning.
+const scheduleGrace = 5 * time.Minute
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "A command to stop and start Roost clusters on a schedule",
+	Long: `A command to define rules which stop or start the clusters matching a selector at recurring times, written in cron
+syntax and read in a time zone, such as stopping the clusters labeled team=web at 20:00 on weekdays and starting them
+at 08:00. The rules are kept next to the config and executed by 'roost schedule run', which records what it did in
+the history.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(config.LoadServerFromViper())
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			cmd.Help()
+			return
+		}
+		if args[0] != "help" {
+			fmt.Printf("%v is not a valid command\n", args[0])
+		}
+		cmd.Help()
+	},
+	Example: `
+	roost schedule add web-night --action stop --cron '0 20 * * mon-fri' --timezone Europe/Berlin --selector team=web
+	roost schedule add web-morning --action start --cron '0 8 * * mon-fri' --timezone Europe/Berlin --selector team=web
+	roost schedule list
+	roost schedule preview
+	roost schedule run
+	roost schedule history
+	roost schedule delete web-night
+	`,
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add name",
+	Short: "Add a schedule rule",
+	Long: `A command to add a rule which stops or starts the clusters matching the selectors whenever the cron expression
+fires. The expression has five fields, minute hour day-of-month month day-of-week, such as '0 20 * * mon-fri', or is
+one of @hourly, @daily, @weekdays, @weekly, @monthly and @yearly. It is read in --timezone, by default the time zone of
+the machine running 'roost schedule run'.
+Stop rules act on the running clusters matching the selectors and start rules on the stopped ones.
+Note: start rules use the start endpoint of 'roost cluster start', which is not confirmed by the Roost API yet.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			cobra.CheckErr(fmt.Errorf("add takes the name of the schedule"))
+		}
+		rule := cluster.ScheduleRule{Name: args[0], CreatedAt: time.Now()}
+		rule.Action, _ = cmd.Flags().GetString("action")
+		rule.Cron, _ = cmd.Flags().GetString("cron")
+		rule.TimeZone, _ = cmd.Flags().GetString("timezone")
+		rule.Selector, _ = cmd.Flags().GetString("selector")
+		rule.FieldSelector, _ = cmd.Flags().GetString("field-selector")
+		rule.All, _ = cmd.Flags().GetBool("all")
+		cobra.CheckErr(rule.Validate())
+
+		schedules, err := cluster.LoadSchedules()
+		cobra.CheckErr(err)
+		if _, ok := schedules[rule.Name]; ok {
+			if overwrite, _ := cmd.Flags().GetBool("overwrite"); !overwrite {
+				cobra.CheckErr(fmt.Errorf("schedule %s already exists, use --overwrite to replace it", rule.Name))
+			}
+		}
+		schedules[rule.Name] = rule
+		cobra.CheckErr(schedules.Save())
+
+		next, _ := rule.Next(time.Now())
+		if next.IsZero() {
+			fmt.Printf("Added schedule %s, but '%s' never fires\n", rule.Name, rule.Cron)
+			return
+		}
+		fmt.Printf("Added schedule %s, it next runs at %s\n", rule.Name, formatScheduleTime(next, time.Now()))
+	},
+	Example: `
+	roost schedule add web-night --action stop --cron '0 20 * * mon-fri' --timezone Europe/Berlin --selector team=web
+	roost schedule add web-morning --action start --cron '0 8 * * 1-5' --timezone Europe/Berlin --selector team=web
+	roost schedule add ci-cleanup --action stop --cron '@hourly' --selector 'email=ci@ourco.com,age>4h'
+	roost schedule add weekend --action stop --cron '0 18 * * fri' --all --overwrite
+	`,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the schedule rules",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		schedules, err := cluster.LoadSchedules()
+		cobra.CheckErr(err)
+		if len(schedules) == 0 {
+			fmt.Println("No schedules, add one with 'roost schedule add'")
+			return
+		}
+		now := time.Now()
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Name", "Action", "Cron", "Time Zone", "Clusters", "Next Run"})
+		t.SetStyle(table.StyleDouble)
+		for _, rule := range schedules.Sorted() {
+			timeZone := rule.TimeZone
+			if timeZone == "" {
+				timeZone = "local"
+			}
+			nextRun := "never"
+			if next, err := rule.Next(now); err != nil {
+				nextRun = err.Error()
+			} else if !next.IsZero() {
+				nextRun = formatScheduleTime(next, now)
+			}
+			t.AppendRow(table.Row{rule.Name, rule.Action, rule.Cron, timeZone, scheduleClusters(rule), nextRun})
+		}
+		t.Render()
+	},
+	Example: `
+	roost schedule list
+	`,
+}
+
+var scheduleDeleteCmd = &cobra.Command{
+	Use:   "delete name...",
+	Short: "Delete schedule rules",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			cobra.CheckErr(fmt.Errorf("delete takes the names of the schedules"))
+		}
+		schedules, err := cluster.LoadSchedules()
+		cobra.CheckErr(err)
+		for _, name := range args {
+			if _, ok := schedules[name]; !ok {
+				cobra.CheckErr(fmt.Errorf("no schedule named %q", name))
+			}
+		}
+		for _, name := range args {
+			delete(schedules, name)
+		}
+		cobra.CheckErr(schedules.Save())
+		fmt.Println("Deleted schedule", strings.Join(args, ", "))
+	},
+	Example: `
+	roost schedule delete web-night
+	roost schedule delete web-night web-morning
+	`,
+}
+
+var schedulePreviewCmd = &cobra.Command{
+	Use:   "preview [name...]",
+	Short: "Preview when the schedule rules run and the clusters they act on",
+	Long: `A command to show the next times the schedule rules run, and the clusters each would stop or start if it ran now,
+without changing anything. 'roost schedule run --dry-run' previews the rules as they fire instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		schedules, err := cluster.LoadSchedules()
+		cobra.CheckErr(err)
+		rules := schedules.Sorted()
+		if len(args) > 0 {
+			rules = nil
+			for _, name := range args {
+				rule, ok := schedules[name]
+				if !ok {
+					cobra.CheckErr(fmt.Errorf("no schedule named %q", name))
+				}
+				rules = append(rules, rule)
+			}
+		}
+		if len(rules) == 0 {
+			fmt.Println("No schedules, add one with 'roost schedule add'")
+			return
+		}
+		count, _ := cmd.Flags().GetInt("count")
+		clusterListData, err := cluster.FetchClusterList(viper.Get("roost_auth_token").(string))
+		cobra.CheckErr(err)
+
+		now := time.Now()
+		for i, rule := range rules {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s: %s %s at '%s'\n", rule.Name, rule.Action, scheduleClusters(rule), rule.Cron)
+			next := now
+			for n := 0; n < count; n++ {
+				if next, err = rule.Next(next); err != nil || next.IsZero() {
+					if n == 0 {
+						fmt.Println("  never runs")
+					}
+					break
+				}
+				fmt.Println("  runs at", formatScheduleTime(next, now))
+			}
+			targets, err := rule.Targets(clusterListData.Clusters)
+			if err != nil {
+				fmt.Println("  Error:", err.Error())
+				continue
+			}
+			if len(targets) == 0 {
+				fmt.Printf("  would %s no clusters now\n", rule.Action)
+				continue
+			}
+			var aliases []string
+			for _, clusterData := range targets {
+				aliases = append(aliases, clusterData.CustomerToken)
+			}
+			fmt.Printf("  would %s now: %s\n", rule.Action, strings.Join(aliases, ", "))
+		}
+	},
+	Example: `
+	roost schedule preview
+	roost schedule preview web-night --count 5
+	`,
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the schedule rules in the foreground",
+	Long: `A command to execute the schedule rules as they fire, stopping and starting clusters with the cluster APIs and
+logging what it does, until it is interrupted. The rules are read again every minute, so rules added or deleted
+meanwhile apply without a restart. Firings while it isn't running are not caught up, and those more than five
+minutes late, such as after the machine slept, are recorded as missed.
+Everything it does is recorded in 'roost schedule history'. With --dry-run the clusters are only listed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		schedules, err := cluster.LoadSchedules()
+		cobra.CheckErr(err)
+		for _, rule := range schedules.Sorted() {
+			cobra.CheckErr(rule.Validate())
+		}
+
+		interrupts := make(chan os.Signal, 1)
+		signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(interrupts)
+
+		mode := ""
+		if dryRun {
+			mode = " in dry-run mode"
+		}
+		logSchedule("running %d schedules%s, press Ctrl-C to stop", len(schedules), mode)
+		last := time.Now()
+		for {
+			// Wake just after every minute, when rules fire.
+			timer := time.NewTimer(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute + time.Second)))
+			select {
+			case <-interrupts:
+				timer.Stop()
+				logSchedule("stopped")
+				return
+			case <-timer.C:
+			}
+			now := time.Now()
+			if schedules, err = cluster.LoadSchedules(); err != nil {
+				logSchedule("unable to read the schedules: %s", err.Error())
+				continue
+			}
+			runSchedules(schedules.Due(last, now), now, dryRun)
+			last = now
+		}
+	},
+	Example: `
+	roost schedule run
+	roost schedule run --dry-run
+	nohup roost schedule run >> roost-schedule.log 2>&1 &
+	`,
+}
+
+// runSchedules executes the rules which fired, logging and recording each of their runs.
+func runSchedules(due []cluster.Firing, now time.Time, dryRun bool) {
+	if len(due) == 0 {
+		return
+	}
+	authToken := viper.Get("roost_auth_token").(string)
+	clusterListData, listErr := cluster.FetchClusterList(authToken)
+
+	var runs []cluster.ScheduleRun
+	for _, firing := range due {
+		rule := firing.Rule
+		record := func(run cluster.ScheduleRun) {
+			run.Rule, run.Action, run.Time, run.DryRun = rule.Name, rule.Action, firing.At, dryRun
+			runs = append(runs, run)
+			target := ""
+			if run.Cluster != "" {
+				target = " " + run.Cluster
+			}
+			logSchedule("%s: %s%s: %s", rule.Name, rule.Action, target, run.Outcome())
+		}
+
+		if late := now.Sub(firing.At); late > scheduleGrace {
+			record(cluster.ScheduleRun{Error: fmt.Sprintf("missed, due %s ago", utils.HumanDuration(late))})
+			continue
+		}
+		if listErr != nil {
+			record(cluster.ScheduleRun{Error: "unable to list the clusters: " + listErr.Error()})
+			continue
+		}
+		targets, err := rule.Targets(clusterListData.Clusters)
+		if err != nil {
+			record(cluster.ScheduleRun{Error: err.Error()})
+			continue
+		}
+		if len(targets) == 0 {
+			record(cluster.ScheduleRun{})
+			continue
+		}
+		for _, clusterData := range targets {
+			run := cluster.ScheduleRun{Cluster: clusterData.CustomerToken}
+			if !dryRun {
+				action := cluster.Stop
+				if rule.Action == cluster.ScheduleStart {
+					action = cluster.Start
+				}
+				if err := action(authToken, clusterData.CustomerToken); err != nil {
+					run.Error = err.Error()
+				}
+			}
+			record(run)
+		}
+	}
+	if err := cluster.RecordScheduleRuns(runs...); err != nil {
+		logSchedule("unable to record the schedule history: %s", err.Error())
+	}
+}
+
+// logSchedule prints a timestamped line of the log of 'roost schedule run'.
+func logSchedule(format string, args ...any) {
+	fmt.Printf("%s %s\n", time.Now().Format("2006-01-02 15:04:05 MST"), fmt.Sprintf(format, args...))
+}
+
+var scheduleHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show what the schedule rules did",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 {
+			if args[0] != "help" {
+				fmt.Printf("%v is not a valid argument to the command %v\n", args[0], cmd.Name())
+			}
+			cmd.Help()
+			return
+		}
+		output, _ := cmd.Flags().GetString("output")
+		if output != "text" && output != "json" {
+			cobra.CheckErr(fmt.Errorf("unknown output format %q, use text or json", output))
+		}
+		history, err := cluster.LoadScheduleHistory()
+		cobra.CheckErr(err)
+		if name, _ := cmd.Flags().GetString("rule"); name != "" {
+			var runs []cluster.ScheduleRun
+			for _, run := range history {
+				if run.Rule == name {
+					runs = append(runs, run)
+				}
+			}
+			history = runs
+		}
+		if limit, _ := cmd.Flags().GetInt("limit"); limit > 0 && len(history) > limit {
+			history = history[len(history)-limit:]
+		}
+
+		if output == "json" {
+			if history == nil {
+				history = []cluster.ScheduleRun{}
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			cobra.CheckErr(encoder.Encode(history))
+			return
+		}
+		if len(history) == 0 {
+			fmt.Println("No schedule runs yet")
+			return
+		}
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Time", "Schedule", "Action", "Cluster", "Outcome"})
+		t.SetStyle(table.StyleDouble)
+		for _, run := range history {
+			clusterAlias := run.Cluster
+			if clusterAlias == "" {
+				clusterAlias = "-"
+			}
+			t.AppendRow(table.Row{run.Time.Local().Format("2006-01-02 15:04 MST"), run.Rule, run.Action, clusterAlias, run.Outcome()})
+		}
+		t.Render()
+	},
+	Example: `
+	roost schedule history
+	roost schedule history --rule web-night --limit 50
+	roost schedule history -o json
+	`,
+}
+
+// scheduleClusters describes the clusters a rule selects.
+func scheduleClusters(rule cluster.ScheduleRule) string {
+	var parts []string
+	if rule.Selector != "" {
+		parts = append(parts, rule.Selector)
+	}
+	if rule.FieldSelector != "" {
+		parts = append(parts, "fields "+rule.FieldSelector)
+	}
+	if len(parts) == 0 {
+		return "all clusters"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatScheduleTime formats a time a rule runs in the local time zone, with how long until then.
+func formatScheduleTime(t, now time.Time) string {
+	return fmt.Sprintf("%s (in %s)", t.Local().Format("Mon 2006-01-02 15:04 MST"), utils.HumanDuration(t.Sub(now)))
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleAddCmd)
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleDeleteCmd)
+	scheduleCmd.AddCommand(schedulePreviewCmd)
+	scheduleCmd.AddCommand(scheduleRunCmd)
+	scheduleCmd.AddCommand(scheduleHistoryCmd)
+
+	scheduleAddCmd.Flags().String("action", "", "REQUIRED. What the rule does to the matching clusters: stop or start")
+	scheduleAddCmd.Flags().String("cron", "", "REQUIRED. When the rule runs, e.g. '0 20 * * mon-fri' or @daily")
+	scheduleAddCmd.Flags().String("timezone", "", "Time zone the cron expression is read in, e.g. Europe/Berlin (default: the local time zone)")
+	addSelectorFlags(scheduleAddCmd)
+	scheduleAddCmd.Flags().Bool("all", false, "Apply the rule to all clusters, when no selector is given")
+	scheduleAddCmd.Flags().Bool("overwrite", false, "Replace an existing schedule")
+
+	schedulePreviewCmd.Flags().Int("count", 3, "How many of the next runs of each rule to show")
+
+	scheduleRunCmd.Flags().Bool("dry-run", false, "Log and record the clusters the rules would stop or start without doing it")
+
+	scheduleHistoryCmd.Flags().String("rule", "", "Only show the runs of this schedule")
+	scheduleHistoryCmd.Flags().Int("limit", 20, "How many of the latest runs to show, 0 for all")
+	scheduleHistoryCmd.Flags().StringP("output", "o", "text", "Output format: text or json")
+}
@@ -9,10 +9,10 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"path/filepath"
 
 	"github.com/ZB-io/internal/roostcli/pkg/cluster"
 	"github.com/ZB-io/internal/roostcli/pkg/config"
+	"github.com/ZB-io/internal/roostcli/pkg/kubeconfig"
 	"github.com/ZB-io/internal/roostcli/pkg/spinner"
 	team "github.com/ZB-io/internal/roostcli/pkg/team"
 	"github.com/ZB-io/internal/roostcli/pkg/utils"
@@ -115,14 +115,18 @@ var teamDelete = &cobra.Command{
 	Long:  "Use 'roost cluster stop --help' for more info",
 	Run: func(cmd *cobra.Command, args []string) {
 		var deleteinfo team.DeleteTeam
+		var selectedTeam team.TeamList
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
 		Teaminfo := teamDetails()
 		teamname, _ := cmd.Flags().GetString("name")
-		for _, teamData := range Teaminfo.Teamlist {
-			if teamData.Name == teamname {
-				deleteinfo.TeamID = teamData.TeamId
-			}
-		}
 		isSet := cmd.Flags().Lookup("name").Changed
+		if isSet {
+			var err error
+			selectedTeam, err = team.Resolve(teamname, Teaminfo.Teamlist)
+			cobra.CheckErr(err)
+			deleteinfo.TeamID = selectedTeam.TeamId
+		}
 
 		if !isSet {
 			columns := []bubbletable.Column{
@@ -146,6 +150,11 @@ var teamDelete = &cobra.Command{
 			UserChoice := utils.TableInput(columns, rows)
 			if len(UserChoice) != 0 {
 				deleteinfo.TeamID = UserChoice[3]
+				for _, teamData := range Teaminfo.Teamlist {
+					if teamData.TeamId == deleteinfo.TeamID {
+						selectedTeam = teamData
+					}
+				}
 			}
 		}
 
@@ -154,9 +163,22 @@ var teamDelete = &cobra.Command{
 			return
 		}
 
+		apiEndPoint := "/api/team/delete"
+		if dryRun {
+			utils.PrintDryRun(http.MethodPost, apiEndPoint, deleteinfo)
+			return
+		}
+		summary := []string{
+			fmt.Sprintf("team %s (ID %s) with %s members", selectedTeam.Name, selectedTeam.TeamId, selectedTeam.MemberCount),
+			"access of all members to the clusters attached to the team",
+		}
+		if !utils.ConfirmDestructive(summary, selectedTeam.Name, yes) {
+			fmt.Println("Aborted, the team was not deleted")
+			return
+		}
+
 		spinner := spinner.NewSpinner()
 		spinner.Start("Deleting the team")
-		apiEndPoint := "/api/team/delete"
 		reqBuff, err := json.Marshal(deleteinfo)
 		if err != nil {
 			fmt.Println(err)
@@ -195,9 +217,8 @@ var teamInviteMember = &cobra.Command{
 		cobra.CheckErr(err)
 		var invitedetails team.InviteMembers
 
-		invitedetails.TeamID, _ = cmd.Flags().GetString("id")
+		invitedetails.TeamID = resolveTeamFlag(cmd).TeamId
 		invitedetails.Username, _ = cmd.Flags().GetStringSlice("members")
-		fmt.Println(invitedetails)
 		spinner := spinner.NewSpinner()
 		spinner.Start("Sending team invites")
 		apiEndPoint := "/api/team/inviteMultiple"
@@ -232,12 +253,19 @@ var teamRemoveMember = &cobra.Command{
 		err := config.LoadServerFromViper()
 		cobra.CheckErr(err)
 		var removedetails team.RemoveMember
-		removedetails.TeamID, _ = cmd.Flags().GetString("id")
+		selectedTeam := resolveTeamFlag(cmd)
+		removedetails.TeamID = selectedTeam.TeamId
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+		apiEndPoint := "/api/team/removeMember"
 		removeMember := func(memberid string) {
 			removedetails.MemberID = memberid
+			if dryRun {
+				utils.PrintDryRun(http.MethodPost, apiEndPoint, removedetails)
+				return
+			}
 			spinner := spinner.NewSpinner()
 			spinner.Start("Removing team member")
-			apiEndPoint := "/api/team/removeMember"
 			reqBuff, err := json.Marshal(removedetails)
 			if err != nil {
 				fmt.Println(err)
@@ -260,6 +288,16 @@ var teamRemoveMember = &cobra.Command{
 			}
 		}
 		MemberIDs, _ := cmd.Flags().GetStringSlice("members")
+		if !dryRun {
+			var summary []string
+			for _, member := range MemberIDs {
+				summary = append(summary, fmt.Sprintf("membership of %s in team %s", member, selectedTeam.Name))
+			}
+			if !utils.ConfirmDestructive(summary, selectedTeam.Name, yes) {
+				fmt.Println("Aborted, no members were removed")
+				return
+			}
+		}
 		for _, member := range MemberIDs {
 			removeMember(member)
 		}
@@ -303,45 +341,81 @@ var getTeamConfig = &cobra.Command{
 			return
 		}
 
-		home, err := os.UserHomeDir()
-		cobra.CheckErr(err)
-		kubeConfigDir := filepath.Join(home, ".kube", "roostteamconfig")
-		var getKubeConfig []team.TeamKubeConfigResponse
+		merge, _ := cmd.Flags().GetBool("merge")
+		switchContext, _ := cmd.Flags().GetBool("switch")
+		if switchContext && !merge {
+			cobra.CheckErr(fmt.Errorf("--switch requires --merge"))
+		}
+
 		spinner := spinner.NewSpinner()
 		spinner.Start("Getting the kubeconfig of the attached team cluster")
-		apiEndPoint := "/api/application/getTeamCluster"
-		reqBuff, err := json.Marshal(kubeconfigteam)
+		teamKubeconfig, err := fetchTeamKubeconfig(kubeconfigteam)
 		if err != nil {
 			spinner.Stop(false)
 			fmt.Println(err)
-			os.Exit(0)
+			return
 		}
-		body := bytes.NewReader(reqBuff)
-		authkey := "Bearer " + viper.Get("roost_auth_token").(string)
-		status, respbody, err := utils.HTTPClientRequest(http.MethodPost, apiEndPoint, authkey, body)
-		cobra.CheckErr(err)
-		err = json.Unmarshal(respbody, &getKubeConfig)
-		cobra.CheckErr(err)
-		if !utils.FileOrFolderExists(kubeConfigDir) {
-			spinner.Stop(false)
-			err := os.MkdirAll(kubeConfigDir, 0755)
+
+		if merge {
+			kubeConfigPath, err := kubeconfig.DefaultPath()
 			cobra.CheckErr(err)
+			contextName := team.ContextName(UserChoice[1])
+			err = kubeconfig.MergeInto(kubeConfigPath, contextName, []byte(teamKubeconfig), switchContext)
+			if err != nil {
+				spinner.Stop(false)
+				cobra.CheckErr(err)
+			}
+			spinner.Stop(true)
+			if switchContext {
+				fmt.Printf("Merged into %s as context %s, which is now the current context.\n", kubeConfigPath, contextName)
+			} else {
+				fmt.Printf("Merged into %s as context %s.\nUse 'kubectl config use-context %s'.\n", kubeConfigPath, contextName, contextName)
+			}
+			return
 		}
-		kubeConfigPath := filepath.Join(kubeConfigDir, UserChoice[1])
 
-		err = os.WriteFile(kubeConfigPath, []byte(getKubeConfig[0].Kubeconfig), 0644)
+		kubeConfigPath, err := team.KubeconfigPath(UserChoice[1])
 		cobra.CheckErr(err)
-		var apiresp cluster.ClusterApiResponse
-		if status == http.StatusCreated {
-			spinner.Stop(true)
-			fmt.Printf("The kubeconfig file is present in $HOME/.kube/roostteamconfig/%s.\nUse 'export KUBECONFIG=$HOME/.kube/roostconfig/%s'.\n", UserChoice[1], UserChoice[1])
-		} else {
+		if err := kubeconfig.WriteFile(kubeConfigPath, []byte(teamKubeconfig)); err != nil {
 			spinner.Stop(false)
-			json.Unmarshal(respbody, &apiresp)
-			fmt.Println("Unable to get the kubeconfig of the requested cluster: ", string(apiresp.ClusterRespMessage))
+			cobra.CheckErr(err)
 		}
-
+		spinner.Stop(true)
+		fmt.Printf("The kubeconfig file is present in $HOME/.kube/roostteamconfig/%s.\nUse 'export KUBECONFIG=$HOME/.kube/roostteamconfig/%s'.\n", UserChoice[1], UserChoice[1])
 	},
+	Example: `
+	roost team get-kubeconfig
+	roost team get-kubeconfig --merge --switch
+	`,
+}
+
+// fetchTeamKubeconfig returns the kubeconfig of the cluster attached to a team.
+func fetchTeamKubeconfig(kubeconfigteam team.TeamKubeConfigObj) (string, error) {
+	var getKubeConfig []team.TeamKubeConfigResponse
+	reqBuff, err := json.Marshal(kubeconfigteam)
+	if err != nil {
+		return "", err
+	}
+	authkey := "Bearer " + viper.Get("roost_auth_token").(string)
+	status, respbody, err := utils.HTTPClientRequest(http.MethodPost, "/api/application/getTeamCluster", authkey, bytes.NewReader(reqBuff))
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusCreated {
+		var apiresp cluster.ClusterApiResponse
+		json.Unmarshal(respbody, &apiresp)
+		return "", fmt.Errorf("Unable to get the kubeconfig of the requested cluster: %s", apiresp.ClusterRespMessage)
+	}
+	if err := json.Unmarshal(respbody, &getKubeConfig); err != nil {
+		return "", err
+	}
+	if len(getKubeConfig) == 0 {
+		return "", fmt.Errorf("The team has no cluster attached, use 'roost team add-cluster' to attach one")
+	}
+	if _, err := kubeconfig.ParseValid([]byte(getKubeConfig[0].Kubeconfig)); err != nil {
+		return "", fmt.Errorf("the kubeconfig of the team cluster is not usable: %s", err.Error())
+	}
+	return getKubeConfig[0].Kubeconfig, nil
 }
 
 var addTeamCluster = &cobra.Command{
@@ -438,8 +512,11 @@ var addTeamCluster = &cobra.Command{
 		clusterInfo.TeamId = teamclusteradd.TeamId
 		clusterInfo.Teamconfig.Restrictuseraccess = true
 
-		utils.AcceptFromPrompt(&clusterInfo.Teamconfig)
-	
+		err = utils.AcceptFromPrompt(&clusterInfo.Teamconfig)
+		if err != nil {
+			cobra.CheckErr(fmt.Errorf("team config prompt error %q", err.Error()))
+		}
+
 		spinner.Start("Updating team details")
 		apiEndPoint = "/api/team/update"
 		reqBuff, err = json.Marshal(clusterInfo)
@@ -468,29 +545,46 @@ var addTeamCluster = &cobra.Command{
 	},
 }
 
+// resolveTeamFlag returns the team given with --team or --id, which may be a team ID, name or unique prefix.
+func resolveTeamFlag(cmd *cobra.Command) team.TeamList {
+	query, _ := cmd.Flags().GetString("team")
+	if !cmd.Flags().Lookup("team").Changed {
+		query, _ = cmd.Flags().GetString("id")
+	}
+	if query == "" {
+		cobra.CheckErr(fmt.Errorf("provide the team with --team or --id"))
+	}
+	teamData, err := team.Resolve(query, teamDetails().Teamlist)
+	cobra.CheckErr(err)
+	return teamData
+}
+
 func teamDetails() team.TeamListResponse {
 	spinner := spinner.NewSpinner()
 	spinner.Start("Fetching teams")
-	apiEndPoint := "/api/team/getMyTeams"
-	var getTeamList team.TeamListResponse
-	authkey := "Bearer " + viper.Get("roost_auth_token").(string)
-	status, resp, err := utils.HTTPClientRequest(http.MethodPost, apiEndPoint, authkey, &bytes.Reader{})
+	getTeamList, err := fetchTeams()
 	if err != nil {
 		spinner.Stop(false)
 		fmt.Println(err)
 		os.Exit(0)
 	}
+	spinner.Stop(true)
+	return getTeamList
+}
 
+// fetchTeams returns the teams the user is part of.
+func fetchTeams() (team.TeamListResponse, error) {
+	var getTeamList team.TeamListResponse
+	authkey := "Bearer " + viper.Get("roost_auth_token").(string)
+	status, resp, err := utils.HTTPClientRequest(http.MethodPost, "/api/team/getMyTeams", authkey, &bytes.Reader{})
+	if err != nil {
+		return getTeamList, err
+	}
 	if status != http.StatusCreated {
-		spinner.Stop(false)
-		fmt.Println("Unable to fetch team list please check the Bearer token", err)
-		return team.TeamListResponse{}
+		return getTeamList, fmt.Errorf("Unable to fetch team list please check the Bearer token")
 	}
 	err = json.Unmarshal(resp, &getTeamList)
-	cobra.CheckErr(err)
-
-	spinner.Stop(true)
-	return getTeamList
+	return getTeamList, err
 }
 
 func init() {
@@ -502,6 +596,8 @@ func init() {
 	teamCmd.AddCommand(teamRemoveMember)
 	teamCmd.AddCommand(addTeamCluster)
 	teamCmd.AddCommand(getTeamConfig)
+	getTeamConfig.Flags().Bool("merge", false, "Merge the kubeconfig into ~/.kube/config (or the first file in $KUBECONFIG) as context roost-team-<team> instead of writing a separate file")
+	getTeamConfig.Flags().Bool("switch", false, "Make the merged context the current context. Requires --merge")
 
 	// Here you will define your flags and configuration settings.
 
@@ -514,18 +610,24 @@ func init() {
 	teamCreate.Flags().String("org", "", "To Specify Organisation")
 	teamCreate.Flags().StringSlice("members", []string{}, "Specify Members in team")
 
-	teamDelete.Flags().String("name", "", "To specify team name to be deleted")
+	teamDelete.Flags().String("name", "", "To specify team name, ID or a unique prefix of either to be deleted")
+	teamDelete.Flags().BoolP("yes", "y", false, "Delete without asking for confirmation")
+	teamDelete.Flags().Bool("dry-run", false, "Print the API call which would be made without deleting anything")
 	//teamDelete.MarkFlagRequired("id")
 
-	teamInviteMember.Flags().String("id", "", "To Specify of Team to be invited")
-	teamInviteMember.Flags().StringSlice("User", []string{}, "Specify Members in team to be invited")
-	teamInviteMember.MarkFlagRequired("id")
+	teamInviteMember.Flags().String("id", "", "To Specify ID of Team to be invited")
+	teamInviteMember.Flags().String("team", "", "To Specify Team to be invited by name, ID or a unique prefix of either")
+	teamInviteMember.Flags().StringSlice("members", []string{}, "Specify Members in team to be invited")
+	teamInviteMember.MarkFlagsMutuallyExclusive("id", "team")
 	teamInviteMember.MarkFlagRequired("members")
 
-	teamRemoveMember.Flags().String("id", "", "To Specify of Team to be invited")
-	teamRemoveMember.Flags().StringSlice("members", []string{}, "Specify Members in team to be invited")
-	teamRemoveMember.MarkFlagRequired("id")
+	teamRemoveMember.Flags().String("id", "", "To Specify ID of Team to remove members from")
+	teamRemoveMember.Flags().String("team", "", "To Specify Team to remove members from by name, ID or a unique prefix of either")
+	teamRemoveMember.Flags().StringSlice("members", []string{}, "Specify Members to be removed from team")
+	teamRemoveMember.MarkFlagsMutuallyExclusive("id", "team")
 	teamRemoveMember.MarkFlagRequired("members")
+	teamRemoveMember.Flags().BoolP("yes", "y", false, "Remove without asking for confirmation")
+	teamRemoveMember.Flags().Bool("dry-run", false, "Print the API calls which would be made without removing anyone")
 
 	//teamCreate.MarkFlagsMutuallyExclusive()
 	// Cobra supports local flags which will only run when this command
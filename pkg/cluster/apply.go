@@ -0,0 +1,164 @@
+package cluster
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Actions of a fleet plan.
+const (
+	ActionCreate    = "create"
+	ActionUnchanged = "unchanged"
+	ActionDrift     = "drift"
+	ActionStop      = "stop"
+	ActionDelete    = "delete"
+)
+
+// Prune modes of 'cluster apply --prune'.
+const (
+	PruneStop   = "stop"
+	PruneDelete = "delete"
+)
+
+// Change is what applying a fleet does to one cluster.
+type Change struct {
+	Action  string
+	Alias   string
+	Desired CreateClusterRequest
+	Current ClusterList
+	// Drift lists how the cluster differs from its spec. The API can't change a launched cluster,
+	// so drift is only reported.
+	Drift []string
+}
+
+// Plan is the list of changes applying a fleet makes, in the order of the spec followed by the pruned clusters.
+type Plan []Change
+
+/*
+MakePlan compares the desired clusters, keyed by alias, with the current ones.
+// Clusters missing from current are created, and existing ones are compared with what was recorded when they were
+// created to report drift. With prune set to PruneStop or PruneDelete, clusters recorded as part of fleet which are no
+// longer desired are stopped or deleted. Clusters which were never applied with this fleet are left alone.
+*/
+func MakePlan(fleet string, desired []CreateClusterRequest, current []ClusterList, records Records, prune string) Plan {
+	var plan Plan
+	wanted := map[string]bool{}
+	for _, spec := range desired {
+		wanted[spec.Alias] = true
+		clusterData, found := FindByAlias(current, spec.Alias)
+		if !found {
+			plan = append(plan, Change{Action: ActionCreate, Alias: spec.Alias, Desired: spec})
+			continue
+		}
+		change := Change{Action: ActionUnchanged, Alias: spec.Alias, Desired: spec, Current: clusterData}
+		change.Drift = drift(spec, clusterData, records)
+		if len(change.Drift) > 0 {
+			change.Action = ActionDrift
+		}
+		plan = append(plan, change)
+	}
+
+	if prune == "" {
+		return plan
+	}
+	var pruned Plan
+	for _, clusterData := range current {
+		record, ok := records.Lookup(clusterData)
+		if !ok || record.Fleet != fleet || wanted[clusterData.CustomerToken] || wanted[clusterData.Alias] {
+			continue
+		}
+		switch {
+		case prune == PruneDelete:
+			pruned = append(pruned, Change{Action: ActionDelete, Alias: clusterData.CustomerToken, Current: clusterData})
+		case Status(clusterData) == StatusRunning || Status(clusterData) == StatusInProgress:
+			pruned = append(pruned, Change{Action: ActionStop, Alias: clusterData.CustomerToken, Current: clusterData})
+		}
+	}
+	sort.Slice(pruned, func(i, j int) bool { return pruned[i].Alias < pruned[j].Alias })
+	return append(plan, pruned...)
+}
+
+// drift compares a spec with the live cluster and with the request it was created with, when known.
+func drift(spec CreateClusterRequest, clusterData ClusterList, records Records) []string {
+	var diffs []string
+	switch Status(clusterData) {
+	case StatusFailed:
+		diffs = append(diffs, "cluster failed: "+clusterData.FailureMsg)
+	case StatusStopped:
+		diffs = append(diffs, "cluster is stopped")
+	}
+	if clusterData.CustomerEmail != "" && !strings.EqualFold(clusterData.CustomerEmail, spec.Email) {
+		diffs = append(diffs, fmt.Sprintf("email: %s → %s", clusterData.CustomerEmail, spec.Email))
+	}
+
+	record, ok := records.Lookup(clusterData)
+	if !ok || record.Request.Alias == "" {
+		return diffs
+	}
+	have := reflect.ValueOf(record.Request)
+	want := reflect.ValueOf(spec)
+	for i := 0; i < have.NumField(); i++ {
+		key := specKeyOf(have.Type().Field(i))
+		if key == "" || key == "-" || key == "alias" || key == "email" {
+			continue
+		}
+		if h, w := fmt.Sprint(have.Field(i).Interface()), fmt.Sprint(want.Field(i).Interface()); h != w {
+			diffs = append(diffs, fmt.Sprintf("%s: %s → %s", key, h, w))
+		}
+	}
+	return diffs
+}
+
+// Count returns how many changes of the plan have the given action.
+func (p Plan) Count(action string) int {
+	n := 0
+	for _, change := range p {
+		if change.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// HasChanges reports whether applying the plan would do anything.
+func (p Plan) HasChanges() bool {
+	return p.Count(ActionCreate)+p.Count(ActionStop)+p.Count(ActionDelete) > 0
+}
+
+// String renders the plan as a diff with one line per cluster, marked + when it is created, ~ when it drifted
+// and - when it is pruned, followed by a summary.
+func (p Plan) String() string {
+	width := 0
+	for _, change := range p {
+		if len(change.Alias) > width {
+			width = len(change.Alias)
+		}
+	}
+	var b strings.Builder
+	for _, change := range p {
+		var sign, detail string
+		switch change.Action {
+		case ActionCreate:
+			sign = "+"
+			detail = fmt.Sprintf("%s, %d workers, %s, k8s %s, %d hours", change.Desired.InstanceType, change.Desired.WorkerNodes, change.Desired.Region, change.Desired.K8sVersion, change.Desired.ClusterExpiry)
+		case ActionDrift:
+			sign = "~"
+			detail = strings.Join(change.Drift, "; ")
+		case ActionStop, ActionDelete:
+			sign = "-"
+			detail = "no longer declared, " + Status(change.Current)
+		default:
+			sign = " "
+			detail = Status(change.Current)
+		}
+		fmt.Fprintf(&b, "%s %-9s %-*s  %s\n", sign, change.Action, width, change.Alias, detail)
+	}
+	fmt.Fprintf(&b, "\nPlan: %d to create, %d to stop, %d to delete, %d drifted, %d unchanged.\n",
+		p.Count(ActionCreate), p.Count(ActionStop), p.Count(ActionDelete), p.Count(ActionDrift), p.Count(ActionUnchanged))
+	if p.Count(ActionDrift) > 0 {
+		b.WriteString("Drift is only reported, launched clusters can't be changed. Delete and apply again to recreate them.\n")
+	}
+	return b.String()
+}
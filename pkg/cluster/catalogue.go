@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/ZB-io/internal/roostcli/pkg/config"
+	"github.com/spf13/viper"
+)
+
+//go:embed catalogue.json
+var bundledCatalogue []byte
+
+/*
+Catalogue lists the values the Roost API accepts for a cluster, so mistakes are caught before a cluster is requested.
+The bundled catalogue can be replaced list by list with catalogue.json next to the config, or the catalogue_file
+setting, when the server offers more than this release of roost knows about.
+*/
+type Catalogue struct {
+	Regions       []string `json:"regions"`
+	InstanceTypes []string `json:"instance_types"`
+	K8sVersions   []string `json:"k8s_versions"`
+	AMIs          []string `json:"amis"`
+	MinDiskGB     int      `json:"min_disk_gb"`
+	MaxDiskGB     int      `json:"max_disk_gb"`
+	MaxWorkers    int      `json:"max_workers"`
+}
+
+var (
+	catalogueOnce   sync.Once
+	loadedCatalogue Catalogue
+	catalogueErr    error
+)
+
+// LoadCatalogue returns the catalogue, read once per run. On error the bundled catalogue is returned with it.
+func LoadCatalogue() (Catalogue, error) {
+	catalogueOnce.Do(func() {
+		loadedCatalogue, catalogueErr = readCatalogue()
+	})
+	return loadedCatalogue, catalogueErr
+}
+
+func readCatalogue() (Catalogue, error) {
+	var catalogue Catalogue
+	if err := json.Unmarshal(bundledCatalogue, &catalogue); err != nil {
+		return catalogue, err
+	}
+	path := viper.GetString("catalogue_file")
+	if path == "" {
+		dir, err := config.Dir()
+		if err != nil {
+			return catalogue, err
+		}
+		path = filepath.Join(dir, "catalogue.json")
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && viper.GetString("catalogue_file") == "" {
+		return catalogue, nil
+	}
+	if err != nil {
+		return catalogue, err
+	}
+	var override Catalogue
+	if err := json.Unmarshal(data, &override); err != nil {
+		return catalogue, fmt.Errorf("invalid catalogue file %s: %s", path, err.Error())
+	}
+	for _, list := range []struct{ to, from *[]string }{
+		{&catalogue.Regions, &override.Regions},
+		{&catalogue.InstanceTypes, &override.InstanceTypes},
+		{&catalogue.K8sVersions, &override.K8sVersions},
+		{&catalogue.AMIs, &override.AMIs},
+	} {
+		if len(*list.from) > 0 {
+			*list.to = *list.from
+		}
+	}
+	for _, limit := range []struct{ to, from *int }{
+		{&catalogue.MinDiskGB, &override.MinDiskGB},
+		{&catalogue.MaxDiskGB, &override.MaxDiskGB},
+		{&catalogue.MaxWorkers, &override.MaxWorkers},
+	} {
+		if *limit.from > 0 {
+			*limit.to = *limit.from
+		}
+	}
+	return catalogue, nil
+}
+
+// FieldOptions returns the values the catalogue allows for a field of the request, see utils.FieldRules.
+func (r CreateClusterRequest) FieldOptions(field string) []string {
+	catalogue, _ := LoadCatalogue()
+	switch field {
+	case "Region":
+		return catalogue.Regions
+	case "InstanceType":
+		return catalogue.InstanceTypes
+	case "K8sVersion":
+		return catalogue.K8sVersions
+	case "Ami":
+		return catalogue.AMIs
+	}
+	return nil
+}
+
+// CheckField checks the limits of the catalogue which tags can't express, see utils.FieldRules.
+func (r CreateClusterRequest) CheckField(field, value string) string {
+	catalogue, _ := LoadCatalogue()
+	switch field {
+	case "DiskSize":
+		gb, err := ParseDiskSize(value)
+		if err != nil {
+			return "must be a size such as 50GB"
+		}
+		if catalogue.MinDiskGB > 0 && gb < catalogue.MinDiskGB {
+			return fmt.Sprintf("must be at least %dGB", catalogue.MinDiskGB)
+		}
+		if catalogue.MaxDiskGB > 0 && gb > catalogue.MaxDiskGB {
+			return fmt.Sprintf("must be at most %dGB", catalogue.MaxDiskGB)
+		}
+	case "WorkerNodes":
+		if workers, err := strconv.Atoi(value); err == nil && catalogue.MaxWorkers > 0 && workers > catalogue.MaxWorkers {
+			return fmt.Sprintf("must be at most %d", catalogue.MaxWorkers)
+		}
+	}
+	return ""
+}
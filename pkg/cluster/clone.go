@@ -0,0 +1,28 @@
+package cluster
+
+// cloneUnknown are the fields of a request the cluster list doesn't tell, so only the record of the cluster knows them.
+var cloneUnknown = []string{"Namespace", "Ami", "InstanceType", "DiskSize", "Region", "ClusterExpiry", "K8sVersion"}
+
+/*
+CloneRequest rebuilds the request a cluster was created with, for 'cluster clone'. Clusters created from this machine
+are cloned faithfully from their record. For the others, only the email and the workers are known from the list, and
+the names of the fields which are missing are returned for the caller to fill.
+// The alias is left empty, as the clone needs its own.
+*/
+func CloneRequest(c ClusterList, records Records) (CreateClusterRequest, []string) {
+	if record, ok := records.Lookup(c); ok && record.Request.InstanceType != "" {
+		request := record.Request
+		request.Alias = ""
+		request.RoostAuthToken = ""
+		if request.Email == "" {
+			request.Email = c.CustomerEmail
+		}
+		return request, nil
+	}
+	request := CreateClusterRequest{Email: c.CustomerEmail, WorkerNodes: c.NumNodes}
+	missing := append([]string{}, cloneUnknown...)
+	if request.WorkerNodes <= 0 {
+		missing = append(missing, "WorkerNodes")
+	}
+	return request, missing
+}
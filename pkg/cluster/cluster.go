@@ -3,10 +3,16 @@ package cluster
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
+	"github.com/ZB-io/internal/roostcli/pkg/kubeconfig"
+	"github.com/ZB-io/internal/roostcli/pkg/resolve"
 	"github.com/ZB-io/internal/roostcli/pkg/spinner"
 	"github.com/ZB-io/internal/roostcli/pkg/utils"
 	"github.com/spf13/cobra"
@@ -14,19 +20,21 @@ import (
 )
 
 // CreateClusterRequest can be used to accept data from promptUI. If prompt tag is not used, field name would apper in UI.
-// Prompted attributes must be an exported field with supported data type int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, ,float32, float64, string
+// See utils.AcceptFromPrompt for the supported types and the help, validate, options and secret tags.
+// The allowed regions, instance types, k8s versions and AMIs and the disk and worker limits come from the Catalogue.
+// The yaml tags are the keys of cluster spec files, see ParseSpecs.
 type CreateClusterRequest struct {
-	Alias          string `json:"alias" prompt:"Cluster Alias"`
-	Email          string `json:"customer_email"`
-	Namespace      string `json:"namespace"`
-	Ami            string `json:"ami"`
-	InstanceType   string `json:"instance_type"`
-	DiskSize       string `json:"disk_size"`
-	Region         string `json:"region"`
-	ClusterExpiry  int    `json:"cluster_expires_in_hours"`
-	K8sVersion     string `json:"k8s_version"`
-	WorkerNodes    int    `json:"num_workers"`
-	RoostAuthToken string `json:"roost_auth_token"`
+	Alias          string `json:"alias" yaml:"alias" prompt:"Cluster Alias" help:"Name used to refer to the cluster in other commands" validate:"required,max=63,regex=^[A-Za-z0-9][A-Za-z0-9._-]*$"`
+	Email          string `json:"customer_email" yaml:"email" prompt:"Email" help:"Email address the cluster is launched for" validate:"required,regex=^[^@\\s]+@[^@\\s]+\\.[^@\\s]+$"`
+	Namespace      string `json:"namespace" yaml:"namespace" prompt:"Namespace" validate:"required,max=63,regex=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"`
+	Ami            string `json:"ami" yaml:"ami" prompt:"AMI" validate:"required"`
+	InstanceType   string `json:"instance_type" yaml:"instance_type" prompt:"Instance Type" help:"EC2 instance type of the nodes"`
+	DiskSize       string `json:"disk_size" yaml:"disk_size" prompt:"Disk Size" help:"Disk size of each node, minimum 50GB" validate:"required,regex=^[0-9]+GB$"`
+	Region         string `json:"region" yaml:"region" prompt:"Region" help:"AWS region to launch the cluster in"`
+	ClusterExpiry  int    `json:"cluster_expires_in_hours" yaml:"expiry_hours" prompt:"Expiry (hours)" help:"The cluster is removed after this many hours" validate:"min=1"`
+	K8sVersion     string `json:"k8s_version" yaml:"k8s_version" prompt:"Kubernetes Version" validate:"required,regex=^[0-9]+\\.[0-9]+\\.[0-9]+$"`
+	WorkerNodes    int    `json:"num_workers" yaml:"workers" prompt:"Worker Nodes" validate:"min=1"`
+	RoostAuthToken string `json:"roost_auth_token" yaml:"-" prompt:"-"`
 }
 
 type ClusterKubeconfig struct {
@@ -107,12 +115,13 @@ func GetClusterDetails(clusterid int, alias string) (ClusterList, error) {
 	}
 
 	if alias != "" {
-		for _, clusterData := range ClusterInfo.Clusters {
-			if clusterData.CustomerToken == alias {
-				spinner.Stop(true)
-				return clusterData, nil
-			}
+		clusters, err := Resolve(ClusterInfo.Clusters, []string{alias}, false)
+		if err != nil {
+			spinner.Stop(false)
+			return ClusterList{}, err
 		}
+		spinner.Stop(true)
+		return clusters[0], nil
 	} else {
 		for _, clusterData := range ClusterInfo.Clusters {
 			if clusterData.Id == clusterid {
@@ -126,6 +135,252 @@ func GetClusterDetails(clusterid int, alias string) (ClusterList, error) {
 	return ClusterList{}, err
 }
 
+/*
+Resolve finds the clusters referred to by the queries, which may be IDs, aliases, unique prefixes of either,
+or globs such as 'ci-*' when allowGlob is set. Every query has to match at least one cluster.
+*/
+func Resolve(clusters []ClusterList, queries []string, allowGlob bool) ([]ClusterList, error) {
+	candidates := make([]resolve.Candidate, len(clusters))
+	for i, clusterData := range clusters {
+		candidates[i] = resolve.Candidate{ID: strconv.Itoa(clusterData.Id), Names: []string{clusterData.CustomerToken, clusterData.Alias}}
+	}
+
+	var resolved []ClusterList
+	seen := map[int]bool{}
+	for _, query := range queries {
+		var matches []int
+		var err error
+		if allowGlob {
+			matches, err = resolve.Resolve("cluster", query, candidates)
+		} else {
+			var match int
+			match, err = resolve.ResolveOne("cluster", query, candidates)
+			matches = []int{match}
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range matches {
+			if !seen[i] {
+				seen[i] = true
+				resolved = append(resolved, clusters[i])
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// IsExactMatch reports whether query is the ID or an alias of the cluster, rather than a prefix or glob Resolve matched.
+func IsExactMatch(c ClusterList, query string) bool {
+	return query == strconv.Itoa(c.Id) || query == c.CustomerToken || (c.Alias != "" && query == c.Alias)
+}
+
+// KubeconfigPath returns where the kubeconfig of the cluster with the given alias is stored.
+func KubeconfigPath(alias string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".kube", "roostconfig", alias), nil
+}
+
+// API endpoints of the cluster operations. The start and extend endpoints are modelled on the stop endpoint and not
+// confirmed by the Roost API yet.
+const (
+	LaunchEndpoint = "/api/application/client/launchCluster"
+	StopEndpoint   = "/api/application/client/stopLaunchedCluster"
+	StartEndpoint  = "/api/application/client/startLaunchedCluster"
+	ExtendEndpoint = "/api/application/client/extendLaunchedCluster"
+	DeleteEndpoint = "/api/application/client/deleteLaunchedCluster"
+)
+
+// ClusterExtendObj pushes out the expiry of a cluster.
+type ClusterExtendObj struct {
+	Alias          string `json:"alias"`
+	RoostAuthToken string `json:"roost_auth_token"`
+	ExtendByHours  int    `json:"extend_by_hours"`
+}
+
+// Launch requests a new cluster and remembers the request locally, see RecordCreate.
+func Launch(request CreateClusterRequest) error {
+	reqBuff, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	status, resp, err := utils.HTTPClientRequest(http.MethodPost, LaunchEndpoint, "", bytes.NewReader(reqBuff))
+	if err != nil {
+		return err
+	}
+	if status != http.StatusCreated {
+		var apiresp ClusterApiResponse
+		json.Unmarshal(resp, &apiresp)
+		return errors.New(apiresp.ClusterRespMessage)
+	}
+	if err := RecordCreate(request, time.Now()); err != nil {
+		fmt.Println("Unable to remember the cluster request locally:", err)
+	}
+	return nil
+}
+
+// Stop stops the cluster with the given alias.
+func Stop(authToken, alias string) error {
+	return clusterOperation(StopEndpoint, authToken, alias)
+}
+
+// Start resumes the stopped cluster with the given alias.
+func Start(authToken, alias string) error {
+	return clusterOperation(StartEndpoint, authToken, alias)
+}
+
+// Extend pushes out the expiry of the cluster with the given alias by hours, and remembers it locally.
+func Extend(authToken, alias string, hours int) error {
+	if err := clusterRequest(ExtendEndpoint, ClusterExtendObj{Alias: alias, RoostAuthToken: authToken, ExtendByHours: hours}); err != nil {
+		return err
+	}
+	return RecordExtend(alias, hours)
+}
+
+// Delete deletes the cluster with the given alias. The local kubeconfig is left for the caller to remove.
+func Delete(authToken, alias string) error {
+	return clusterOperation(DeleteEndpoint, authToken, alias)
+}
+
+func clusterOperation(apiEndPoint, authToken, alias string) error {
+	return clusterRequest(apiEndPoint, ClusterStopObj{Alias: alias, RoostAuthToken: authToken})
+}
+
+func clusterRequest(apiEndPoint string, request any) error {
+	reqBuff, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	status, resp, err := utils.HTTPClientRequest(http.MethodPost, apiEndPoint, "", bytes.NewReader(reqBuff))
+	if err != nil {
+		return err
+	}
+	if status != http.StatusCreated {
+		var apiresp ClusterApiResponse
+		json.Unmarshal(resp, &apiresp)
+		return errors.New(apiresp.ClusterRespMessage)
+	}
+	return nil
+}
+
+// GetKubeconfig fetches the kubeconfig of the cluster with the given alias.
+func GetKubeconfig(authToken, alias string) (ClusterKubeconfigResponse, error) {
+	var kubeconfig ClusterKubeconfigResponse
+	reqBuff, err := json.Marshal(ClusterKubeconfig{Alias: alias, RoostAuthToken: authToken})
+	if err != nil {
+		return kubeconfig, err
+	}
+	status, resp, err := utils.HTTPClientRequest(http.MethodPost, "/api/application/cluster/getKubeConfig", "", bytes.NewReader(reqBuff))
+	if err != nil {
+		return kubeconfig, err
+	}
+	if status != http.StatusCreated {
+		var apiresp ClusterApiResponse
+		json.Unmarshal(resp, &apiresp)
+		return kubeconfig, fmt.Errorf("unable to get the kubeconfig of cluster %s: %s", alias, apiresp.ClusterRespMessage)
+	}
+	if err := json.Unmarshal(resp, &kubeconfig); err != nil {
+		return kubeconfig, err
+	}
+	if kubeconfig.Kubeconfig == "" {
+		return kubeconfig, fmt.Errorf("the kubeconfig of cluster %s is not available yet", alias)
+	}
+	return kubeconfig, nil
+}
+
+// ContextPrefix starts the names of the contexts cluster kubeconfigs are merged under. It differs from team.ContextPrefix
+// in a way no alias can make up for, so a cluster aliased team-web is never taken for the context of team web.
+const ContextPrefix = "roost-cluster-"
+
+// ContextName is the name of the context, cluster and user a cluster's kubeconfig is merged under.
+func ContextName(alias string) string {
+	return ContextPrefix + alias
+}
+
+
+// MergeKubeconfig fetches the kubeconfig of the cluster with the given alias and merges it into the default
+// kubeconfig under ContextName, optionally switching to it. It returns the path of the default kubeconfig.
+func MergeKubeconfig(authToken, alias string, switchContext bool) (string, error) {
+	config, err := GetKubeconfig(authToken, alias)
+	if err != nil {
+		return "", err
+	}
+	if _, err := kubeconfig.ParseValid([]byte(config.Kubeconfig)); err != nil {
+		return "", fmt.Errorf("the kubeconfig of cluster %s is not usable: %s", alias, err.Error())
+	}
+	path, err := kubeconfig.DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	return path, kubeconfig.MergeInto(path, ContextName(alias), []byte(config.Kubeconfig), switchContext)
+}
+
+// LocalKubeconfigs describes what RemoveLocalKubeconfig would remove for the cluster with the given alias.
+func LocalKubeconfigs(alias string) []string {
+	var found []string
+	if kubeConfigPath, err := KubeconfigPath(alias); err == nil && utils.FileOrFolderExists(kubeConfigPath) {
+		found = append(found, "local kubeconfig "+kubeConfigPath)
+	}
+	if defaultPath, err := kubeconfig.DefaultPath(); err == nil {
+		if config, err := kubeconfig.Load(defaultPath); err == nil {
+			if _, ok := config.Context(ContextName(alias)); ok {
+				found = append(found, fmt.Sprintf("context %s in %s", ContextName(alias), defaultPath))
+			}
+		}
+	}
+	return found
+}
+
+// RemoveLocalKubeconfig removes the downloaded kubeconfig of a cluster and its entries in the default kubeconfig.
+// It returns what was removed.
+func RemoveLocalKubeconfig(alias string) ([]string, error) {
+	var removed []string
+	kubeConfigPath, err := KubeconfigPath(alias)
+	if err != nil {
+		return nil, err
+	}
+	if utils.FileOrFolderExists(kubeConfigPath) {
+		if err := os.Remove(kubeConfigPath); err != nil {
+			return removed, err
+		}
+		removed = append(removed, kubeConfigPath)
+	}
+	defaultPath, err := kubeconfig.DefaultPath()
+	if err != nil {
+		return removed, err
+	}
+	ok, err := kubeconfig.RemoveFrom(defaultPath, ContextName(alias))
+	if ok {
+		removed = append(removed, fmt.Sprintf("context %s in %s", ContextName(alias), defaultPath))
+	}
+	return removed, err
+}
+
+// SaveKubeconfig fetches the kubeconfig of the cluster with the given alias and stores it at KubeconfigPath.
+func SaveKubeconfig(authToken, alias string) (string, error) {
+	kubeconfig, err := GetKubeconfig(authToken, alias)
+	if err != nil {
+		return "", err
+	}
+	return StoreKubeconfig(alias, kubeconfig.Kubeconfig)
+}
+
+// StoreKubeconfig stores the kubeconfig of the cluster with the given alias at KubeconfigPath, readable only by its
+// owner. An unusable kubeconfig is not stored, so it never replaces a working one.
+func StoreKubeconfig(alias, data string) (string, error) {
+	kubeConfigPath, err := KubeconfigPath(alias)
+	if err != nil {
+		return "", err
+	}
+	if _, err := kubeconfig.ParseValid([]byte(data)); err != nil {
+		return "", fmt.Errorf("the kubeconfig of cluster %s is not usable: %s", alias, err.Error())
+	}
+	return kubeConfigPath, kubeconfig.WriteFile(kubeConfigPath, []byte(data))
+}
+
 //ClusterList is used get cluster details and list,To be used in teams section also
 func GetClusterList(authToken string) (list ClusterListResponse) {
 	clusterListObj := ClusterListObj{}
@@ -0,0 +1,186 @@
+package cluster
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// apiRequest is a request received by the fake API server.
+type apiRequest struct {
+	Path string
+	Body map[string]any
+}
+
+/*
+fakeAPI serves the Roost API with the given status and message, and keeps the local stores of the test in a
+temporary directory. It returns the requests it received.
+*/
+func fakeAPI(t *testing.T, status int, message string) *[]apiRequest {
+	t.Helper()
+	var requests []apiRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := apiRequest{Path: r.URL.Path}
+		if r.Method != http.MethodPost {
+			t.Errorf("%s %s, want POST", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request.Body); err != nil {
+			t.Errorf("%s: invalid payload: %v", r.URL.Path, err)
+		}
+		requests = append(requests, request)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"message": message})
+	}))
+	t.Cleanup(server.Close)
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.SetConfigFile(filepath.Join(t.TempDir(), "config.json"))
+	viper.Set("roost_ent_server", server.URL)
+	return &requests
+}
+
+func TestClusterOperations(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func() error
+		path     string
+		wantBody map[string]any
+	}{
+		{
+			name:     "stop",
+			call:     func() error { return Stop("token", "web") },
+			path:     StopEndpoint,
+			wantBody: map[string]any{"alias": "web", "roost_auth_token": "token"},
+		},
+		{
+			name:     "start",
+			call:     func() error { return Start("token", "web") },
+			path:     StartEndpoint,
+			wantBody: map[string]any{"alias": "web", "roost_auth_token": "token"},
+		},
+		{
+			name:     "extend",
+			call:     func() error { return Extend("token", "web", 3) },
+			path:     ExtendEndpoint,
+			wantBody: map[string]any{"alias": "web", "roost_auth_token": "token", "extend_by_hours": float64(3)},
+		},
+		{
+			name:     "delete",
+			call:     func() error { return Delete("token", "web") },
+			path:     DeleteEndpoint,
+			wantBody: map[string]any{"alias": "web", "roost_auth_token": "token"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := fakeAPI(t, http.StatusCreated, "")
+			if err := tt.call(); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if len(*requests) != 1 {
+				t.Fatalf("%d requests, want 1", len(*requests))
+			}
+			got := (*requests)[0]
+			if got.Path != tt.path {
+				t.Errorf("path = %s, want %s", got.Path, tt.path)
+			}
+			for key, want := range tt.wantBody {
+				if got.Body[key] != want {
+					t.Errorf("payload %s = %v, want %v", key, got.Body[key], want)
+				}
+			}
+			if len(got.Body) != len(tt.wantBody) {
+				t.Errorf("payload = %v, want %v", got.Body, tt.wantBody)
+			}
+		})
+
+		t.Run(tt.name+" failure", func(t *testing.T) {
+			for _, status := range []int{http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError} {
+				fakeAPI(t, status, "cluster is busy")
+				if err := tt.call(); err == nil || err.Error() != "cluster is busy" {
+					t.Errorf("status %d: error = %v, want the message of the API", status, err)
+				}
+			}
+		})
+	}
+}
+
+func TestExtendRecordsLocally(t *testing.T) {
+	fakeAPI(t, http.StatusCreated, "")
+	if err := (Records{"web": {Alias: "web", Request: CreateClusterRequest{ClusterExpiry: 2}}}).Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Extend("token", "web", 3); err != nil {
+		t.Fatal(err)
+	}
+	// Clusters created elsewhere have no record to extend.
+	if err := Extend("token", "db", 3); err != nil {
+		t.Fatal(err)
+	}
+	records, err := LoadRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := records["web"].ExpiryHours(); got != 5 {
+		t.Errorf("expiry of web = %d hours, want 5", got)
+	}
+	if _, ok := records["db"]; ok {
+		t.Error("extend recorded a cluster created elsewhere")
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	viper.Set("roost_ent_server", failing.URL)
+	if err := Extend("token", "web", 3); err == nil {
+		t.Fatal("Extend() succeeded against a failing API")
+	}
+	if records, _ := LoadRecords(); records["web"].ExpiryHours() != 5 {
+		t.Errorf("expiry of web after a failed extend = %d hours, want 5", records["web"].ExpiryHours())
+	}
+}
+
+func TestIsExactMatch(t *testing.T) {
+	c := ClusterList{Id: 42, CustomerToken: "web-prod", Alias: "web"}
+	for query, want := range map[string]bool{"42": true, "web-prod": true, "web": true, "4": false, "web-": false, "w*": false} {
+		if got := IsExactMatch(c, query); got != want {
+			t.Errorf("IsExactMatch(%q) = %v, want %v", query, got, want)
+		}
+	}
+	if IsExactMatch(ClusterList{Id: 1, CustomerToken: "db"}, "") {
+		t.Error("an empty query matched a cluster without an alias")
+	}
+}
+
+func TestFetchKeepsMetadata(t *testing.T) {
+	fakeAPI(t, http.StatusCreated, "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(ClusterListResponse{Count: 1, Clusters: []ClusterList{{Id: 1, CustomerToken: "web"}}})
+	}))
+	defer server.Close()
+	viper.Set("roost_ent_server", server.URL)
+	store := MetadataStore{"1": {Alias: "web"}, "2": {Alias: "db", Labels: map[string]string{"project": "checkout"}}}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// db missing from one fetch, as from a poll while the API lags, keeps its labels.
+	if _, err := FetchClusterList("token"); err != nil {
+		t.Fatal(err)
+	}
+	GetClusterList("token")
+	store, err := LoadMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store["2"].Labels["project"] != "checkout" {
+		t.Errorf("fetching the cluster list dropped the labels of db, metadata = %v", store)
+	}
+}
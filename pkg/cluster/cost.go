@@ -0,0 +1,272 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/pricing"
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+)
+
+// ParseDiskSize returns the gigabytes of a disk size such as 50GB.
+func ParseDiskSize(size string) (int, error) {
+	gb, err := strconv.Atoi(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(size)), "GB"))
+	if err != nil || gb <= 0 {
+		return 0, fmt.Errorf("invalid disk size %q, use a size such as 50GB", size)
+	}
+	return gb, nil
+}
+
+// Rates are the hourly prices of the nodes of a cluster.
+type Rates struct {
+	// Nodes counts the control plane nodes as well as the workers.
+	Nodes        int
+	InstanceType string
+	Region       string
+	DiskGB       int
+	NodeHourly   float64
+	DiskHourly   float64
+}
+
+// Hourly returns the price of an hour of the running cluster.
+func (r Rates) Hourly() float64 {
+	return float64(r.Nodes) * (r.NodeHourly + r.DiskHourly)
+}
+
+// RequestRates returns the rates a cluster created with the request would be charged.
+func RequestRates(request CreateClusterRequest, table pricing.Table) (Rates, error) {
+	rates := Rates{Nodes: request.WorkerNodes + table.ControlPlaneNodes, InstanceType: request.InstanceType, Region: request.Region}
+	var err error
+	if rates.DiskGB, err = ParseDiskSize(request.DiskSize); err != nil {
+		return rates, err
+	}
+	if rates.NodeHourly, err = table.NodeHourly(request.InstanceType, request.Region); err != nil {
+		return rates, err
+	}
+	rates.DiskHourly, err = table.DiskHourly(rates.DiskGB, request.Region)
+	return rates, err
+}
+
+// Usage is how long a listed cluster has run and kept its disks, and what it is charged for that.
+type Usage struct {
+	Cluster ClusterList
+	Rates   Rates
+	// Assumed is set when the cluster wasn't created from this machine, so the defaults of the pricing table stand
+	// in for its instance type, region and disk size.
+	Assumed bool
+	// Running is when the cluster last started running, Until when it stopped or now. Both are zero when the cluster
+	// never ran or its times are unknown.
+	Running, Until time.Time
+	// Created is when the disks were created, zero when unknown. They are charged until now, stopped or not.
+	Created time.Time
+}
+
+/*
+ClusterUsage works out the usage of a listed cluster from its times. The list only tells the last time a cluster
+started and stopped, so the usage of clusters which were stopped and started again is a lower bound.
+// Failed clusters are assumed to have never run.
+*/
+func ClusterUsage(c ClusterList, records Records, table pricing.Table, now time.Time) (Usage, error) {
+	usage := Usage{Cluster: c}
+	request := CreateClusterRequest{InstanceType: table.DefaultInstanceType, Region: table.DefaultRegion, DiskSize: "50GB", WorkerNodes: c.NumNodes}
+	if record, ok := records.Lookup(c); ok && record.Request.InstanceType != "" {
+		request = record.Request
+	} else {
+		usage.Assumed = true
+		if request.WorkerNodes <= 0 {
+			request.WorkerNodes = 1
+		}
+	}
+	var err error
+	if usage.Rates, err = RequestRates(request, table); err != nil {
+		return usage, fmt.Errorf("cluster %s: %s", c.CustomerToken, err.Error())
+	}
+
+	created, _ := utils.ParseTime(c.CreatedOn)
+	running, _ := utils.ParseTime(c.RunningOn)
+	stopped, _ := utils.ParseTime(c.StoppedOn)
+	usage.Created = created
+	if running.IsZero() {
+		running = created
+	}
+	switch Status(c) {
+	case StatusRunning, StatusInProgress:
+		usage.Running, usage.Until = running, now
+	case StatusStopped:
+		if !running.Before(stopped) {
+			running = created
+		}
+		if !running.IsZero() && running.Before(stopped) {
+			usage.Running, usage.Until = running, stopped
+		}
+	}
+	return usage, nil
+}
+
+// NodeHours returns the node hours the cluster ran between from and to.
+func (u Usage) NodeHours(from, to time.Time) float64 {
+	return float64(u.Rates.Nodes) * overlap(u.Running, u.Until, from, to).Hours()
+}
+
+// Cost returns what the cluster was charged between from and to.
+func (u Usage) Cost(from, to time.Time) float64 {
+	diskHours := float64(u.Rates.Nodes) * overlap(u.Created, to, from, to).Hours()
+	return u.NodeHours(from, to)*u.Rates.NodeHourly + diskHours*u.Rates.DiskHourly
+}
+
+func overlap(start, end, from, to time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// Columns 'cluster cost' can group by.
+const (
+	CostByEmail   = "email"
+	CostByType    = "type"
+	CostByCluster = "cluster"
+	CostByPeriod  = "period"
+)
+
+// CostGroupings are the columns 'cluster cost' can group by.
+var CostGroupings = []string{CostByEmail, CostByType, CostByCluster, CostByPeriod}
+
+// Periods 'cluster cost' can split costs into.
+var CostPeriods = []string{"day", "week", "month"}
+
+// CostLine is the cost of a group of clusters in a cost report.
+type CostLine struct {
+	// Group holds the values of the grouped columns, in the order they were asked for.
+	Group     []string `json:"group"`
+	Clusters  int      `json:"clusters"`
+	NodeHours float64  `json:"node_hours"`
+	Cost      float64  `json:"cost"`
+}
+
+/*
+CostReport adds up the cost of the clusters between from and to, grouped by the given columns. With the period column,
+costs are split by day, week or month in the local time zone.
+// Groups which cost nothing are left out. Lines are sorted by their group.
+*/
+func CostReport(usages []Usage, groupBy []string, period string, from, to time.Time) ([]CostLine, error) {
+	for _, column := range groupBy {
+		if !contains(CostGroupings, column) {
+			return nil, fmt.Errorf("cannot group by %q, use %s", column, strings.Join(CostGroupings, ", "))
+		}
+	}
+	if !contains(CostPeriods, period) {
+		return nil, fmt.Errorf("unknown period %q, use %s", period, strings.Join(CostPeriods, ", "))
+	}
+	type bucket struct {
+		label    string
+		from, to time.Time
+	}
+	buckets := []bucket{{"", from, to}}
+	if contains(groupBy, CostByPeriod) {
+		buckets = nil
+		for start := periodStart(from, period); start.Before(to); start = nextPeriod(start, period) {
+			end := nextPeriod(start, period)
+			label := start.Format("2006-01-02")
+			if period == "month" {
+				label = start.Format("2006-01")
+			}
+			buckets = append(buckets, bucket{label, maxTime(start, from), minTime(end, to)})
+		}
+	}
+
+	lines := map[string]*CostLine{}
+	clusters := map[string]map[int]bool{}
+	for _, b := range buckets {
+		for _, usage := range usages {
+			cost := usage.Cost(b.from, b.to)
+			if cost == 0 {
+				continue
+			}
+			var group []string
+			for _, column := range groupBy {
+				switch column {
+				case CostByEmail:
+					group = append(group, usage.Cluster.CustomerEmail)
+				case CostByType:
+					group = append(group, usage.Cluster.ClusterType)
+				case CostByCluster:
+					group = append(group, usage.Cluster.CustomerToken)
+				case CostByPeriod:
+					group = append(group, b.label)
+				}
+			}
+			key := strings.Join(group, "\x00")
+			line, ok := lines[key]
+			if !ok {
+				line = &CostLine{Group: group}
+				lines[key] = line
+				clusters[key] = map[int]bool{}
+			}
+			if !clusters[key][usage.Cluster.Id] {
+				clusters[key][usage.Cluster.Id] = true
+				line.Clusters++
+			}
+			line.NodeHours += usage.NodeHours(b.from, b.to)
+			line.Cost += cost
+		}
+	}
+
+	report := make([]CostLine, 0, len(lines))
+	for _, line := range lines {
+		report = append(report, *line)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return strings.Join(report[i].Group, "\x00") < strings.Join(report[j].Group, "\x00")
+	})
+	return report, nil
+}
+
+func periodStart(t time.Time, period string) time.Time {
+	t = t.Local()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	switch period {
+	case "week":
+		// Weeks start on Monday.
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+	}
+	return day
+}
+
+func nextPeriod(start time.Time, period string) time.Time {
+	switch period {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,438 @@
+package cluster
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/viper"
+)
+
+// dashboardEvents is how many recent status changes the dashboard keeps below the table.
+const dashboardEvents = 5
+
+var (
+	dashboardBorder   = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderForeground(lipgloss.Color("240"))
+	dashboardTitle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("50"))
+	dashboardDim      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	dashboardChanged  = lipgloss.NewStyle().Foreground(lipgloss.Color("220"))
+	dashboardFailed   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	dashboardFinished = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+)
+
+var dashboardColumns = []table.Column{
+	{Title: "ID", Width: 6},
+	{Title: "Alias", Width: 24},
+	{Title: "Status", Width: 14},
+	{Title: "Type", Width: 8},
+	{Title: "Nodes", Width: 5},
+	{Title: "Public IP", Width: 15},
+	{Title: "Age", Width: 7},
+	{Title: "Expires In", Width: 10},
+	{Title: "Message", Width: 30},
+}
+
+// Filter narrows down the clusters shown by the dashboard, e.g. with a selector.
+type Filter func([]ClusterList) ([]ClusterList, error)
+
+type dashboardListMsg struct {
+	seq      int
+	clusters []ClusterList
+	records  Records
+	err      error
+}
+
+type dashboardActionMsg struct {
+	text string
+	err  error
+}
+
+type dashboardTickMsg time.Time
+
+// dashboardConfirm is an action on a cluster waiting to be confirmed with y, or with the alias typed when typed is set.
+type dashboardConfirm struct {
+	verb  string
+	alias string
+	typed bool
+	// input is what was typed so far.
+	input string
+}
+
+type dashboard struct {
+	authToken string
+	interval  time.Duration
+	filter    Filter
+
+	table    table.Model
+	clusters []ClusterList
+	records  Records
+	statuses map[int]string
+	changed  map[int]time.Time
+	events   []string
+	updated  time.Time
+
+	// fetches numbers the fetches of the cluster list, so the result of an older one arriving late is dropped.
+	fetches  int
+	fetching bool
+
+	message string
+	err     error
+	confirm dashboardConfirm
+	details bool
+}
+
+/*
+RunDashboard shows the clusters in a full screen table which is refreshed every interval.
+// Status changes are highlighted for a couple of refreshes and logged below the table. The selected cluster can be
+// stopped, deleted, opened in the browser or have its kubeconfig downloaded.
+*/
+func RunDashboard(authToken string, interval time.Duration, filter Filter) error {
+	keys := table.DefaultKeyMap()
+	keys.LineUp.SetKeys("up")
+	keys.LineDown.SetKeys("down")
+	keys.PageUp.SetKeys("pgup")
+	keys.PageDown.SetKeys("pgdown")
+	keys.HalfPageUp.SetKeys("ctrl+u")
+	keys.HalfPageDown.SetKeys("ctrl+d")
+	keys.GotoTop.SetKeys("home")
+	keys.GotoBottom.SetKeys("end")
+
+	t := table.New(
+		table.WithColumns(dashboardColumns),
+		table.WithFocused(true),
+		table.WithHeight(10),
+		table.WithKeyMap(keys),
+	)
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		BorderBottom(true).
+		Bold(false)
+	s.Selected = s.Selected.
+		Foreground(lipgloss.Color("50")).
+		Background(lipgloss.Color("240")).
+		Bold(true)
+	t.SetStyles(s)
+
+	m := dashboard{
+		authToken: authToken,
+		interval:  interval,
+		filter:    filter,
+		table:     t,
+		statuses:  map[int]string{},
+		changed:   map[int]time.Time{},
+		message:   "Fetching the cluster list",
+		fetches:   1,
+		fetching:  true,
+	}
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func (m dashboard) Init() tea.Cmd {
+	return tea.Batch(m.list(m.fetches), m.tick())
+}
+
+func (m dashboard) tick() tea.Cmd {
+	return tea.Tick(m.interval, func(t time.Time) tea.Msg { return dashboardTickMsg(t) })
+}
+
+// fetch starts a new fetch of the cluster list.
+func (m dashboard) fetch() (dashboard, tea.Cmd) {
+	m.fetches++
+	m.fetching = true
+	return m, m.list(m.fetches)
+}
+
+func (m dashboard) list(seq int) tea.Cmd {
+	return func() tea.Msg {
+		list, err := FetchClusterList(m.authToken)
+		if err != nil {
+			return dashboardListMsg{seq: seq, err: err}
+		}
+		clusters := list.Clusters
+		if m.filter != nil {
+			clusters, err = m.filter(clusters)
+		}
+		records, _ := LoadRecords()
+		return dashboardListMsg{seq: seq, clusters: clusters, records: records, err: err}
+	}
+}
+
+// selected returns the cluster under the cursor.
+func (m dashboard) selected() (ClusterList, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.clusters) {
+		return ClusterList{}, false
+	}
+	return m.clusters[i], true
+}
+
+// action runs fn for the selected cluster in the background and reports its outcome.
+func (m dashboard) action(verb string, fn func(c ClusterList) (string, error)) (dashboard, tea.Cmd) {
+	c, ok := m.selected()
+	if !ok {
+		return m, nil
+	}
+	m.message = fmt.Sprintf("%s %s", verb, c.CustomerToken)
+	m.err = nil
+	return m, func() tea.Msg {
+		text, err := fn(c)
+		return dashboardActionMsg{text: text, err: err}
+	}
+}
+
+func (m dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Leave room for the title, borders, the change log and the help line.
+		if h := msg.Height - 8 - dashboardEvents; h > 0 {
+			m.table.SetHeight(h)
+		}
+		return m, nil
+
+	case dashboardTickMsg:
+		// Against a slow API, fetches would pile up.
+		if m.fetching {
+			return m, m.tick()
+		}
+		var cmd tea.Cmd
+		m, cmd = m.fetch()
+		return m, tea.Batch(cmd, m.tick())
+
+	case dashboardListMsg:
+		if msg.seq != m.fetches {
+			return m, nil
+		}
+		m.fetching = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		if m.message == "Fetching the cluster list" {
+			m.message = ""
+		}
+		m.refresh(msg.clusters, msg.records, time.Now())
+		return m, nil
+
+	case dashboardActionMsg:
+		m.message, m.err = msg.text, msg.err
+		return m.fetch()
+
+	case tea.KeyMsg:
+		if m.confirm.alias != "" {
+			confirmed := msg.String() == "y"
+			if m.confirm.typed {
+				switch msg.Type {
+				case tea.KeyRunes, tea.KeySpace:
+					m.confirm.input += string(msg.Runes)
+					return m, nil
+				case tea.KeyBackspace:
+					if input := []rune(m.confirm.input); len(input) > 0 {
+						m.confirm.input = string(input[:len(input)-1])
+					}
+					return m, nil
+				}
+				confirmed = msg.Type == tea.KeyEnter && m.confirm.input == m.confirm.alias
+			}
+			confirm := m.confirm
+			m.confirm = dashboardConfirm{}
+			if !confirmed {
+				m.message = strings.ToUpper(confirm.verb[:1]) + confirm.verb[1:] + " cancelled"
+				return m, nil
+			}
+			if confirm.verb == "stop" {
+				return m.action("Stopping", func(c ClusterList) (string, error) {
+					return "Stopped " + confirm.alias, Stop(m.authToken, confirm.alias)
+				})
+			}
+			return m.action("Deleting", func(c ClusterList) (string, error) {
+				if err := Delete(m.authToken, confirm.alias); err != nil {
+					return "", err
+				}
+				if _, err := RemoveLocalKubeconfig(confirm.alias); err != nil {
+					return "", err
+				}
+				return "Deleted " + confirm.alias, Forget(confirm.alias)
+			})
+		}
+
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			if m.details {
+				m.details = false
+				return m, nil
+			}
+			return m, tea.Quit
+		case "enter", "i":
+			m.details = !m.details
+			return m, nil
+		case "r":
+			m.message = "Refreshing"
+			return m.fetch()
+		case "s", "d":
+			if c, ok := m.selected(); ok {
+				m.confirm = dashboardConfirm{verb: "delete", alias: c.CustomerToken, typed: viper.GetBool("require_typed_confirmation")}
+				if msg.String() == "s" {
+					m.confirm = dashboardConfirm{verb: "stop", alias: c.CustomerToken}
+				}
+			}
+			return m, nil
+		case "k":
+			return m.action("Fetching the kubeconfig of", func(c ClusterList) (string, error) {
+				path, err := SaveKubeconfig(m.authToken, c.CustomerToken)
+				return "Kubeconfig saved to " + path, err
+			})
+		case "o":
+			return m.action("Opening the UI of", func(c ClusterList) (string, error) {
+				return "Opened " + UIURL(c), utils.Openbrowser(UIURL(c))
+			})
+		}
+	}
+
+	var cmd tea.Cmd
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+// refresh replaces the clusters, noting which ones changed status since the previous refresh.
+func (m *dashboard) refresh(clusters []ClusterList, records Records, now time.Time) {
+	first := m.updated.IsZero()
+	for _, c := range clusters {
+		status := Status(c)
+		if previous, ok := m.statuses[c.Id]; ok && previous != status {
+			m.changed[c.Id] = now
+			m.events = append(m.events, fmt.Sprintf("%s %s: %s → %s", now.Format("15:04:05"), c.CustomerToken, previous, status))
+		} else if !ok && !first {
+			m.changed[c.Id] = now
+			m.events = append(m.events, fmt.Sprintf("%s %s: new, %s", now.Format("15:04:05"), c.CustomerToken, status))
+		}
+		m.statuses[c.Id] = status
+	}
+	if len(m.events) > dashboardEvents {
+		m.events = m.events[len(m.events)-dashboardEvents:]
+	}
+	m.clusters = clusters
+	m.records = records
+	m.updated = now
+
+	// Changes stay highlighted for two refreshes.
+	highlight := 2 * m.interval
+	rows := make([]table.Row, len(clusters))
+	for i, c := range clusters {
+		status := Status(c)
+		switch {
+		case status == StatusFailed:
+			status = "! " + status
+		case now.Sub(m.changed[c.Id]) < highlight:
+			status = "* " + status
+		}
+		age := "-"
+		if d, ok := Age(c, now); ok {
+			age = utils.HumanDuration(d)
+		}
+		expires := "-"
+		if d, ok := records.ExpiresIn(c, now); ok {
+			expires = utils.HumanDuration(d)
+		}
+		message := c.StatusMsg
+		if c.FailureMsg != "" {
+			message = c.FailureMsg
+		}
+		rows[i] = table.Row{strconv.Itoa(c.Id), c.CustomerToken, status, c.ClusterType, strconv.Itoa(c.NumNodes), c.PublicIP, age, expires, message}
+	}
+	m.table.SetRows(rows)
+	if m.table.Cursor() >= len(rows) {
+		m.table.SetCursor(len(rows) - 1)
+	}
+}
+
+func (m dashboard) View() string {
+	var b strings.Builder
+	title := fmt.Sprintf("Roost clusters (%d)", len(m.clusters))
+	if !m.updated.IsZero() {
+		title += dashboardDim.Render(fmt.Sprintf("  updated %s, every %s", m.updated.Format("15:04:05"), m.interval))
+	}
+	b.WriteString(dashboardTitle.Render(title) + "\n")
+
+	if m.details {
+		b.WriteString(dashboardBorder.Render(m.detailsView()) + "\n")
+	} else {
+		b.WriteString(dashboardBorder.Render(m.table.View()) + "\n")
+	}
+
+	for _, event := range m.events {
+		if strings.HasSuffix(event, StatusFailed) {
+			b.WriteString(dashboardFailed.Render(event) + "\n")
+		} else {
+			b.WriteString(dashboardChanged.Render(event) + "\n")
+		}
+	}
+
+	switch {
+	case m.confirm.verb == "stop":
+		b.WriteString(dashboardFailed.Render(fmt.Sprintf("Stop %s? [y/N]", m.confirm.alias)) + "\n")
+	case m.confirm.typed:
+		b.WriteString(dashboardFailed.Render(fmt.Sprintf("Delete %s? This can't be undone. Type %q and enter to confirm, esc to cancel: %s", m.confirm.alias, m.confirm.alias, m.confirm.input)) + "\n")
+	case m.confirm.alias != "":
+		b.WriteString(dashboardFailed.Render(fmt.Sprintf("Delete %s? This can't be undone. [y/N]", m.confirm.alias)) + "\n")
+	case m.err != nil:
+		b.WriteString(dashboardFailed.Render("Error: "+m.err.Error()) + "\n")
+	case m.message != "":
+		b.WriteString(dashboardFinished.Render(m.message) + "\n")
+	}
+	b.WriteString(dashboardDim.Render("↑/↓ move • enter details • s stop • d delete • k kubeconfig • o open UI • r refresh • q quit") + "\n")
+	return b.String()
+}
+
+func (m dashboard) detailsView() string {
+	c, ok := m.selected()
+	if !ok {
+		return "No cluster selected"
+	}
+	lines := [][2]string{
+		{"ID", strconv.Itoa(c.Id)},
+		{"Alias", c.CustomerToken},
+		{"Email", c.CustomerEmail},
+		{"Status", Phase(c)},
+		{"Type", c.ClusterType},
+		{"Environment", c.EnvType},
+		{"Nodes", strconv.Itoa(c.NumNodes)},
+		{"Public IP", c.PublicIP},
+		{"Created On", c.CreatedOn},
+		{"Running On", c.RunningOn},
+		{"Stopped On", c.StoppedOn},
+	}
+	if record, ok := m.records.Lookup(c); ok {
+		if expiresAt, ok := record.ExpiresAt(c); ok {
+			lines = append(lines, [2]string{"Expires At", expiresAt.Local().Format(time.RFC1123)})
+		}
+		lines = append(lines,
+			[2]string{"Region", record.Request.Region},
+			[2]string{"Instance Type", record.Request.InstanceType},
+			[2]string{"K8s Version", record.Request.K8sVersion},
+		)
+	}
+	if c.FailureMsg != "" {
+		lines = append(lines, [2]string{"Failure", c.FailureMsg}, [2]string{"Details", c.FailureDetails})
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		if line[1] == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%-14s %s\n", line[0]+":", line[1])
+	}
+	b.WriteString(dashboardDim.Render("esc back"))
+	return b.String()
+}
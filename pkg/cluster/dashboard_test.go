@@ -0,0 +1,143 @@
+package cluster
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/viper"
+)
+
+func newTestDashboard() dashboard {
+	return dashboard{
+		interval: time.Minute,
+		statuses: map[int]string{},
+		changed:  map[int]time.Time{},
+		fetches:  1,
+		fetching: true,
+	}
+}
+
+func update(t *testing.T, m dashboard, msg tea.Msg) (dashboard, tea.Cmd) {
+	t.Helper()
+	model, cmd := m.Update(msg)
+	return model.(dashboard), cmd
+}
+
+func TestDashboardDropsStaleLists(t *testing.T) {
+	m := newTestDashboard()
+	m, _ = update(t, m, dashboardListMsg{seq: 1, clusters: []ClusterList{{Id: 1, CustomerToken: "web", IsActive: true}}})
+	if m.fetching {
+		t.Fatal("still fetching after the list arrived")
+	}
+
+	// Refreshing twice, the first answer arrives last.
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m, _ = update(t, m, dashboardListMsg{seq: 3, clusters: []ClusterList{{Id: 1, CustomerToken: "web", StatusMsg: "Stopped"}}})
+	m, _ = update(t, m, dashboardListMsg{seq: 2, clusters: []ClusterList{{Id: 1, CustomerToken: "web", IsActive: true}}})
+	if got := m.statuses[1]; got != StatusStopped {
+		t.Errorf("status = %s, want the stopped status of the latest fetch", got)
+	}
+	if len(m.events) != 1 {
+		t.Errorf("events = %v, want only the change to stopped", m.events)
+	}
+}
+
+func TestDashboardTickWaitsForFetch(t *testing.T) {
+	m := newTestDashboard()
+	m, _ = update(t, m, dashboardTickMsg(time.Now()))
+	if m.fetches != 1 {
+		t.Errorf("a tick during a fetch started fetch %d", m.fetches)
+	}
+	m, _ = update(t, m, dashboardListMsg{seq: 1})
+	m, _ = update(t, m, dashboardTickMsg(time.Now()))
+	if m.fetches != 2 || !m.fetching {
+		t.Errorf("a tick after the fetch returned didn't fetch again")
+	}
+}
+
+func TestDashboardConfirmsStop(t *testing.T) {
+	m := newTestDashboard()
+	m, _ = update(t, m, dashboardListMsg{seq: 1, clusters: []ClusterList{{Id: 1, CustomerToken: "web", IsActive: true}}})
+
+	m, cmd := update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if cmd != nil || m.confirm != (dashboardConfirm{verb: "stop", alias: "web"}) {
+		t.Fatalf("s didn't ask to confirm, confirm = %+v", m.confirm)
+	}
+	m, cmd = update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if cmd != nil || m.confirm.alias != "" || m.message != "Stop cancelled" {
+		t.Errorf("n didn't cancel the stop, message = %q", m.message)
+	}
+
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if m.confirm != (dashboardConfirm{verb: "delete", alias: "web"}) {
+		t.Fatalf("d didn't ask to confirm, confirm = %+v", m.confirm)
+	}
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.message != "Delete cancelled" {
+		t.Errorf("esc didn't cancel the delete, message = %q", m.message)
+	}
+}
+
+func TestDashboardDeleteForgetsRecord(t *testing.T) {
+	requests := fakeAPI(t, http.StatusCreated, "")
+	t.Setenv("HOME", t.TempDir())
+	if err := (Records{"web": {Alias: "web"}, "db": {Alias: "db"}}).Save(); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestDashboard()
+	m, _ = update(t, m, dashboardListMsg{seq: 1, clusters: []ClusterList{{Id: 1, CustomerToken: "web", IsActive: true}}})
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	_, cmd := update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if cmd == nil {
+		t.Fatal("y didn't delete")
+	}
+	if msg := cmd().(dashboardActionMsg); msg.err != nil {
+		t.Fatalf("delete failed: %v", msg.err)
+	}
+	if len(*requests) != 1 || (*requests)[0].Path != DeleteEndpoint {
+		t.Errorf("requests = %v, want a delete", *requests)
+	}
+	records, err := LoadRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := records["web"]; ok || len(records) != 1 {
+		t.Errorf("records = %v, want only db", records)
+	}
+}
+
+func TestDashboardTypedDelete(t *testing.T) {
+	requests := fakeAPI(t, http.StatusCreated, "")
+	t.Setenv("HOME", t.TempDir())
+	viper.Set("require_typed_confirmation", true)
+	m := newTestDashboard()
+	m, _ = update(t, m, dashboardListMsg{seq: 1, clusters: []ClusterList{{Id: 1, CustomerToken: "web", IsActive: true}}})
+
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m, cmd := update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if cmd != nil || !m.confirm.typed || m.confirm.input != "y" {
+		t.Fatalf("y confirmed a delete needing the alias typed, confirm = %+v", m.confirm)
+	}
+	m, cmd = update(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd != nil || m.confirm.alias != "" || m.message != "Delete cancelled" {
+		t.Fatalf("a wrong name didn't cancel the delete, message = %q", m.message)
+	}
+
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("wex")})
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyBackspace})
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	_, cmd = update(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("typing the alias didn't delete")
+	}
+	if msg := cmd().(dashboardActionMsg); msg.err != nil {
+		t.Fatalf("delete failed: %v", msg.err)
+	}
+	if len(*requests) != 1 || (*requests)[0].Path != DeleteEndpoint {
+		t.Errorf("requests = %v, want a delete", *requests)
+	}
+}
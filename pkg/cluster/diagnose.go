@@ -0,0 +1,156 @@
+package cluster
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+)
+
+// Hint is a known cause of cluster failures, recognised by patterns in the failure message and details.
+type Hint struct {
+	Cause    string
+	Patterns []*regexp.Regexp
+	// Fix may use {instance_type}, {region}, {ami}, {k8s_version} and {alias}, filled from the request of the cluster.
+	Fix string
+}
+
+func patterns(exprs ...string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(exprs))
+	for i, expr := range exprs {
+		compiled[i] = regexp.MustCompile("(?i)" + expr)
+	}
+	return compiled
+}
+
+// Hints is the catalogue of known failure causes, in the order they are reported.
+var Hints = []Hint{
+	{
+		Cause:    "no capacity",
+		Patterns: patterns(`InsufficientInstanceCapacity`, `insufficient\s+capacity`, `capacity\s+(is\s+)?not\s+available`, `out\s+of\s+capacity`),
+		Fix:      "AWS has no {instance_type} capacity in {region} right now. Retry later, or create the cluster with another --instance-type or --region.",
+	},
+	{
+		Cause:    "quota exceeded",
+		Patterns: patterns(`VcpuLimitExceeded`, `InstanceLimitExceeded`, `VolumeLimitExceeded`, `LimitExceeded`, `quota`, `limit\s+(has\s+been\s+)?(exceeded|reached)`),
+		Fix:      "The account has hit an AWS service quota. Delete the clusters which are no longer needed ('roost cluster list --stopped'), use fewer or smaller nodes, or ask your Roost admin to raise the quota.",
+	},
+	{
+		Cause:    "AMI not available",
+		Patterns: patterns(`InvalidAMIID`, `\bami\b.*(not\s+found|does\s+not\s+exist|invalid|not\s+available)`, `image.*(not\s+found|does\s+not\s+exist)`),
+		Fix:      "The AMI {ami} isn't available in {region}. Create the cluster with an AMI of the catalogue, see 'roost cluster create --help'.",
+	},
+	{
+		Cause:    "expired credentials",
+		Patterns: patterns(`ExpiredToken`, `RequestExpired`, `token\s+(has\s+)?expired`, `credentials?\s+(have\s+|has\s+)?expired`, `AuthFailure`, `InvalidClientTokenId`, `SignatureDoesNotMatch`),
+		Fix:      "The cloud credentials Roost launched the cluster with were rejected or have expired. Ask your Roost admin to renew them, then delete and recreate the cluster.",
+	},
+	{
+		Cause:    "missing permissions",
+		Patterns: patterns(`UnauthorizedOperation`, `AccessDenied`, `not\s+authorized`),
+		Fix:      "The cloud credentials of Roost lack a permission the cluster needs. Share the failure details with your Roost admin.",
+	},
+	{
+		Cause:    "instance type not supported",
+		Patterns: patterns(`InvalidInstanceType`, `Unsupported.*instance`, `instance\s+type.*not\s+supported`),
+		Fix:      "{instance_type} isn't offered in {region} or its availability zone. Create the cluster with another --instance-type or --region.",
+	},
+	{
+		Cause:    "Kubernetes version not supported",
+		Patterns: patterns(`(kubernetes|k8s|kubeadm|kubelet).*version.*(not\s+supported|unsupported|invalid|not\s+found)`, `unsupported.*(kubernetes|k8s)\s+version`),
+		Fix:      "Kubernetes {k8s_version} can't be installed. Create the cluster with a k8s version of the catalogue, see 'roost cluster create --help'.",
+	},
+	{
+		Cause:    "no free addresses",
+		Patterns: patterns(`InsufficientFreeAddressesInSubnet`, `AddressLimitExceeded`, `no\s+(free|available)\s+(ip\s+)?address`),
+		Fix:      "The network Roost launches clusters in has run out of addresses. Delete unused clusters or ask your Roost admin to free some.",
+	},
+	{
+		Cause:    "timed out",
+		Patterns: patterns(`timed?\s*out`, `deadline\s+exceeded`),
+		Fix:      "Launching the cluster took too long, which is usually transient. Delete it and create it again with 'roost cluster create --preset' or the same flags.",
+	},
+}
+
+// Finding is a hint whose patterns matched a failure.
+type Finding struct {
+	Cause string `json:"cause"`
+	Fix   string `json:"fix"`
+}
+
+// MatchHints returns the hints matching the failure message and details, with their fixes filled from the request,
+// which may be nil when the cluster wasn't created from this machine.
+func MatchHints(c ClusterList, request *CreateClusterRequest) []Finding {
+	text := c.FailureMsg + "\n" + c.FailureDetails
+	values := map[string]string{"instance_type": "the instance type", "region": "the region", "ami": "the AMI", "k8s_version": "the requested version", "alias": c.CustomerToken}
+	if request != nil {
+		for key, value := range map[string]string{"instance_type": request.InstanceType, "region": request.Region, "ami": request.Ami, "k8s_version": request.K8sVersion} {
+			if value != "" {
+				values[key] = value
+			}
+		}
+	}
+	var pairs []string
+	for key, value := range values {
+		pairs = append(pairs, "{"+key+"}", value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	var findings []Finding
+	for _, hint := range Hints {
+		for _, pattern := range hint.Patterns {
+			if pattern.MatchString(text) {
+				findings = append(findings, Finding{Cause: hint.Cause, Fix: replacer.Replace(hint.Fix)})
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// Event is a point of the timeline of a cluster.
+type Event struct {
+	Name string    `json:"event"`
+	Time time.Time `json:"time"`
+}
+
+// Diagnosis is what 'cluster diagnose' reports about a cluster.
+type Diagnosis struct {
+	Cluster ClusterList `json:"cluster"`
+	Status  string      `json:"status"`
+	// Request is nil when the cluster wasn't created from this machine.
+	Request  *CreateClusterRequest `json:"request,omitempty"`
+	Timeline []Event               `json:"timeline"`
+	Findings []Finding             `json:"findings"`
+}
+
+// Diagnose gathers the status, request, timeline and matching hints of a cluster.
+func Diagnose(c ClusterList, records Records) Diagnosis {
+	diagnosis := Diagnosis{Cluster: c, Status: Status(c), Timeline: []Event{}}
+	record, known := records.Lookup(c)
+	if known && record.Request.InstanceType != "" {
+		request := record.Request
+		diagnosis.Request = &request
+	}
+
+	if known && !record.CreatedAt.IsZero() {
+		diagnosis.Timeline = append(diagnosis.Timeline, Event{"requested", record.CreatedAt})
+	}
+	for _, event := range []struct{ name, value string }{{"created", c.CreatedOn}, {"running", c.RunningOn}, {"stopped", c.StoppedOn}} {
+		if t, err := utils.ParseTime(event.value); err == nil {
+			diagnosis.Timeline = append(diagnosis.Timeline, Event{event.name, t})
+		}
+	}
+	if expiresAt, ok := record.ExpiresAt(c); known && ok && diagnosis.Status == StatusRunning {
+		diagnosis.Timeline = append(diagnosis.Timeline, Event{"expires", expiresAt})
+	}
+	sort.SliceStable(diagnosis.Timeline, func(i, j int) bool { return diagnosis.Timeline[i].Time.Before(diagnosis.Timeline[j].Time) })
+
+	diagnosis.Findings = MatchHints(c, diagnosis.Request)
+	if diagnosis.Findings == nil {
+		diagnosis.Findings = []Finding{}
+	}
+	return diagnosis
+}
@@ -0,0 +1,55 @@
+package cluster
+
+import (
+	"os"
+
+	"github.com/ZB-io/internal/roostcli/pkg/kubeconfig"
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+)
+
+// Namespace returns the namespace the cluster with the given alias was created with, if it was created from this machine.
+func Namespace(alias string) string {
+	records, _ := LoadRecords()
+	return records[alias].Request.Namespace
+}
+
+/*
+ExecKubeconfig prepares a kubeconfig to run tools against the cluster with the given alias. It reuses the kubeconfig
+// downloaded to KubeconfigPath and downloads it when there is none yet.
+// With a namespace, it returns a temporary copy whose current context uses that namespace, so the downloaded kubeconfig
+// is left as it is. cleanup removes the copy and has to be called once the tools are done.
+*/
+func ExecKubeconfig(authToken, alias, namespace string) (path string, cleanup func(), err error) {
+	cleanup = func() {}
+	path, err = KubeconfigPath(alias)
+	if err != nil {
+		return "", cleanup, err
+	}
+	if !utils.FileOrFolderExists(path) {
+		if path, err = SaveKubeconfig(authToken, alias); err != nil {
+			return "", cleanup, err
+		}
+	}
+	if namespace == "" {
+		return path, cleanup, nil
+	}
+
+	config, err := kubeconfig.Load(path)
+	if err != nil {
+		return "", cleanup, err
+	}
+	if err := config.SetNamespace(namespace); err != nil {
+		return "", cleanup, err
+	}
+	tmp, err := os.CreateTemp("", "roost-"+alias+"-*.kubeconfig")
+	if err != nil {
+		return "", cleanup, err
+	}
+	tmp.Close()
+	cleanup = func() { os.Remove(tmp.Name()) }
+	if err := config.Save(tmp.Name()); err != nil {
+		cleanup()
+		return "", func() {}, err
+	}
+	return tmp.Name(), cleanup, nil
+}
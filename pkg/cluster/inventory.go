@@ -0,0 +1,140 @@
+package cluster
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/kubeconfig"
+	"github.com/ZB-io/internal/roostcli/pkg/team"
+)
+
+// Kinds of local kubeconfigs.
+const (
+	KubeconfigCluster = "cluster"
+	KubeconfigTeam    = "team"
+)
+
+// States of a local kubeconfig besides the status of its cluster.
+const (
+	StateGone    = "gone"
+	StateExpired = "expired"
+	StateUnknown = "-"
+)
+
+// LocalKubeconfig is a kubeconfig roost left on this machine, either a downloaded file or a context merged into the
+// default kubeconfig.
+type LocalKubeconfig struct {
+	Kind string
+	// Name is the cluster alias or the team name.
+	Name string
+	Path string
+	// Context is the name of the merged context, empty for downloaded files.
+	Context string
+	Server  string
+	State   string
+}
+
+// Location describes where the kubeconfig is.
+func (l LocalKubeconfig) Location() string {
+	if l.Context != "" {
+		return "context " + l.Context + " in " + l.Path
+	}
+	return l.Path
+}
+
+// Stale reports whether the cluster of the kubeconfig is gone, stopped or expired.
+func (l LocalKubeconfig) Stale() bool {
+	return l.State == StateGone || l.State == StateExpired || l.State == StatusStopped
+}
+
+// FindLocalKubeconfigs lists the kubeconfigs in ~/.kube/roostconfig and ~/.kube/roostteamconfig and the roost contexts
+// of the default kubeconfig, with State unknown.
+func FindLocalKubeconfigs() ([]LocalKubeconfig, error) {
+	clusterDir, err := KubeconfigPath("")
+	if err != nil {
+		return nil, err
+	}
+	teamDir, err := team.KubeconfigPath("")
+	if err != nil {
+		return nil, err
+	}
+	var locals []LocalKubeconfig
+	for kind, dir := range map[string]string{KubeconfigCluster: clusterDir, KubeconfigTeam: teamDir} {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			local := LocalKubeconfig{Kind: kind, Name: entry.Name(), Path: filepath.Join(dir, entry.Name()), State: StateUnknown}
+			if config, err := kubeconfig.Load(local.Path); err == nil {
+				local.Server = config.Server("")
+			}
+			locals = append(locals, local)
+		}
+	}
+
+	defaultPath, err := kubeconfig.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	config, err := kubeconfig.Load(defaultPath)
+	if err != nil {
+		return nil, err
+	}
+	// Only the contexts roost merged are listed, others named roost-something are the user's own.
+	for kind, prefix := range map[string]string{KubeconfigCluster: ContextPrefix, KubeconfigTeam: team.ContextPrefix} {
+		for _, contextName := range config.ContextsWithPrefix(prefix) {
+			locals = append(locals, LocalKubeconfig{Kind: kind, Name: strings.TrimPrefix(contextName, prefix), Path: defaultPath, Context: contextName, Server: config.Server(contextName), State: StateUnknown})
+		}
+	}
+
+	sort.SliceStable(locals, func(i, j int) bool {
+		if locals[i].Kind != locals[j].Kind {
+			return locals[i].Kind < locals[j].Kind
+		}
+		return locals[i].Name < locals[j].Name
+	})
+	return locals, nil
+}
+
+// SetClusterStates sets the State of the cluster kubeconfigs from the cluster list: the status of the cluster, expired
+// when its recorded expiry has passed, or gone when it isn't listed anymore.
+func SetClusterStates(locals []LocalKubeconfig, clusters []ClusterList, records Records, now time.Time) {
+	for i := range locals {
+		if locals[i].Kind != KubeconfigCluster {
+			continue
+		}
+		clusterData, found := FindByAlias(clusters, locals[i].Name)
+		if !found {
+			locals[i].State = StateGone
+			continue
+		}
+		locals[i].State = Status(clusterData)
+		if left, ok := records.ExpiresIn(clusterData, now); ok && left <= 0 {
+			locals[i].State = StateExpired
+		}
+	}
+}
+
+// RemoveLocal removes a downloaded kubeconfig, or the entries of a merged context.
+func RemoveLocal(local LocalKubeconfig) error {
+	if local.Context != "" {
+		_, err := kubeconfig.RemoveFrom(local.Path, local.Context)
+		return err
+	}
+	err := os.Remove(local.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
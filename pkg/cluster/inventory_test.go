@@ -0,0 +1,107 @@
+package cluster
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/kubeconfig"
+)
+
+// withContexts points the default kubeconfig to a temporary one with the given contexts, and clusters and users named alike.
+func withContexts(t *testing.T, names ...string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, ".kube", "config")
+	t.Setenv("KUBECONFIG", path)
+	config := &kubeconfig.Config{APIVersion: "v1", Kind: "Config"}
+	for _, name := range names {
+		config.Contexts = append(config.Contexts, kubeconfig.NamedContext{Name: name, Context: kubeconfig.Context{Cluster: name, User: name}})
+		config.Clusters = append(config.Clusters, kubeconfig.NamedCluster{Name: name, Cluster: kubeconfig.Cluster{Server: "https://" + name}})
+		config.Users = append(config.Users, kubeconfig.NamedUser{Name: name, User: kubeconfig.User{Token: name}})
+	}
+	if err := config.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFindLocalKubeconfigsContexts(t *testing.T) {
+	withContexts(t, "roost-cluster-team-web", "roost-team-web", "roost-db", "kind-local")
+	locals, err := FindLocalKubeconfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, local := range locals {
+		got[local.Context] = local.Kind + " " + local.Name
+	}
+	want := map[string]string{
+		"roost-cluster-team-web": KubeconfigCluster + " team-web",
+		"roost-team-web":         KubeconfigTeam + " web",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("contexts = %v, want %v", got, want)
+	}
+}
+
+func TestRemoveLocalKubeconfigContexts(t *testing.T) {
+	path := withContexts(t, "roost-cluster-team-web", "roost-team-web", "roost-cluster-db", "roost-db")
+
+	if removed, err := RemoveLocalKubeconfig("team-web"); err != nil || len(removed) != 1 {
+		t.Fatalf("RemoveLocalKubeconfig(team-web) = %v, %v, want only its context", removed, err)
+	}
+	if removed, err := RemoveLocalKubeconfig("db"); err != nil || len(removed) != 1 {
+		t.Fatalf("RemoveLocalKubeconfig(db) = %v, %v, want only its context", removed, err)
+	}
+
+	config, err := kubeconfig.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left []string
+	for _, context := range config.Contexts {
+		left = append(left, context.Name)
+	}
+	if want := []string{"roost-team-web", "roost-db"}; !reflect.DeepEqual(left, want) {
+		t.Errorf("contexts left = %v, want the team context and the user's own %v", left, want)
+	}
+}
+
+func TestPruneKeepsUnrelatedContexts(t *testing.T) {
+	path := withContexts(t, "roost-cluster-web", "roost-cluster-gone", "roost-foo", "roost-staging")
+	locals, err := FindLocalKubeconfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// As kubeconfig prune does, with none of the clusters named like the user's own contexts.
+	SetClusterStates(locals, []ClusterList{{Id: 1, CustomerToken: "web", IsActive: true}}, Records{}, time.Now())
+	for _, local := range locals {
+		if local.Stale() {
+			if err := RemoveLocal(local); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	config, err := kubeconfig.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contexts, clusters, users []string
+	for _, context := range config.Contexts {
+		contexts = append(contexts, context.Name)
+	}
+	for _, cluster := range config.Clusters {
+		clusters = append(clusters, cluster.Name)
+	}
+	for _, user := range config.Users {
+		users = append(users, user.Name)
+	}
+	want := []string{"roost-cluster-web", "roost-foo", "roost-staging"}
+	if !reflect.DeepEqual(contexts, want) || !reflect.DeepEqual(clusters, want) || !reflect.DeepEqual(users, want) {
+		t.Errorf("left contexts %v, clusters %v and users %v, want %v of each", contexts, clusters, users, want)
+	}
+}
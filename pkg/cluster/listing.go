@@ -0,0 +1,168 @@
+package cluster
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+)
+
+// Statuses are the states Status derives, in the order they are listed in help and errors.
+var Statuses = []string{StatusRunning, StatusStopped, StatusFailed, StatusInProgress, StatusUnknown}
+
+// ListFilter picks clusters for 'cluster list'. Empty fields match every cluster.
+type ListFilter struct {
+	Statuses []string
+	Types    []string
+	Envs     []string
+	// Email and Name are globs such as '*@ourco.com' or 'ci-*', matched without regard to case.
+	Email         string
+	Name          string
+	CreatedBefore time.Time
+	CreatedAfter  time.Time
+}
+
+// Validate checks the statuses and patterns of the filter.
+func (f ListFilter) Validate() error {
+	for _, status := range f.Statuses {
+		if !contains(Statuses, status) {
+			return fmt.Errorf("unknown status %q, use one of %s", status, strings.Join(Statuses, ", "))
+		}
+	}
+	for _, pattern := range []string{f.Email, f.Name} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %s", pattern, err.Error())
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the cluster passes every filter. Clusters whose creation time is unknown don't pass the
+// created filters.
+func (f ListFilter) Matches(c ClusterList) bool {
+	if len(f.Statuses) > 0 && !contains(f.Statuses, Status(c)) {
+		return false
+	}
+	if len(f.Types) > 0 && !containsFold(f.Types, c.ClusterType) {
+		return false
+	}
+	if len(f.Envs) > 0 && !containsFold(f.Envs, c.EnvType) {
+		return false
+	}
+	if f.Email != "" && !globFold(f.Email, c.CustomerEmail) {
+		return false
+	}
+	if f.Name != "" && !globFold(f.Name, c.CustomerToken) && !globFold(f.Name, c.Alias) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() || !f.CreatedAfter.IsZero() {
+		created, err := utils.ParseTime(c.CreatedOn)
+		if err != nil {
+			return false
+		}
+		if !f.CreatedBefore.IsZero() && !created.Before(f.CreatedBefore) {
+			return false
+		}
+		if !f.CreatedAfter.IsZero() && !created.After(f.CreatedAfter) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the clusters matching f.
+func (f ListFilter) Filter(clusters []ClusterList) []ClusterList {
+	var matched []ClusterList
+	for _, clusterData := range clusters {
+		if f.Matches(clusterData) {
+			matched = append(matched, clusterData)
+		}
+	}
+	return matched
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func globFold(pattern, value string) bool {
+	ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return ok
+}
+
+// sortKeys compare two clusters by one column of 'cluster list'. Times compare by their parsed value, so unknown
+// times sort first.
+var sortKeys = map[string]func(a, b ClusterList, records Records, now time.Time) bool{
+	"id":      func(a, b ClusterList, _ Records, _ time.Time) bool { return a.Id < b.Id },
+	"alias":   func(a, b ClusterList, _ Records, _ time.Time) bool { return a.CustomerToken < b.CustomerToken },
+	"email":   func(a, b ClusterList, _ Records, _ time.Time) bool { return a.CustomerEmail < b.CustomerEmail },
+	"ip":      func(a, b ClusterList, _ Records, _ time.Time) bool { return a.PublicIP < b.PublicIP },
+	"nodes":   func(a, b ClusterList, _ Records, _ time.Time) bool { return a.NumNodes < b.NumNodes },
+	"status":  func(a, b ClusterList, _ Records, _ time.Time) bool { return Status(a) < Status(b) },
+	"type":    func(a, b ClusterList, _ Records, _ time.Time) bool { return a.ClusterType < b.ClusterType },
+	"env":     func(a, b ClusterList, _ Records, _ time.Time) bool { return a.EnvType < b.EnvType },
+	"failure": func(a, b ClusterList, _ Records, _ time.Time) bool { return a.FailureMsg < b.FailureMsg },
+	"created": func(a, b ClusterList, _ Records, _ time.Time) bool { return timeBefore(a.CreatedOn, b.CreatedOn) },
+	"running": func(a, b ClusterList, _ Records, _ time.Time) bool { return timeBefore(a.RunningOn, b.RunningOn) },
+	"stopped": func(a, b ClusterList, _ Records, _ time.Time) bool { return timeBefore(a.StoppedOn, b.StoppedOn) },
+	// Clusters without a known expiry sort last.
+	"time-left": func(a, b ClusterList, records Records, now time.Time) bool {
+		left, okA := records.ExpiresIn(a, now)
+		right, okB := records.ExpiresIn(b, now)
+		if okA != okB {
+			return okA
+		}
+		return left < right
+	},
+}
+
+// SortColumns returns the columns 'cluster list --sort-by' accepts.
+func SortColumns() []string {
+	var columns []string
+	for column := range sortKeys {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// SortList sorts the clusters by a column of SortColumns, keeping the order of clusters which compare equal.
+func SortList(clusters []ClusterList, column string, reverse bool, records Records, now time.Time) error {
+	less, ok := sortKeys[column]
+	if !ok {
+		return fmt.Errorf("cannot sort by %q, use one of %s", column, strings.Join(SortColumns(), ", "))
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if reverse {
+			return less(clusters[j], clusters[i], records, now)
+		}
+		return less(clusters[i], clusters[j], records, now)
+	})
+	return nil
+}
+
+func timeBefore(a, b string) bool {
+	ta, errA := utils.ParseTime(a)
+	tb, errB := utils.ParseTime(b)
+	if errA != nil || errB != nil {
+		return errA != nil && errB == nil
+	}
+	return ta.Before(tb)
+}
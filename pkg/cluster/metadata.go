@@ -0,0 +1,200 @@
+package cluster
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of metadata set with 'cluster label' and 'cluster annotate'.
+const (
+	MetadataLabels      = "labels"
+	MetadataAnnotations = "annotations"
+)
+
+// Metadata is what was attached to a cluster on this machine. Labels can be used in selectors, annotations are free form notes.
+type Metadata struct {
+	// Alias tells a cluster apart from a later one the server gives the same id.
+	Alias       string            `json:"alias"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Get returns the labels or the annotations.
+func (m Metadata) Get(kind string) map[string]string {
+	if kind == MetadataAnnotations {
+		return m.Annotations
+	}
+	return m.Labels
+}
+
+// MetadataStore is the metadata of clusters keyed by cluster id, kept next to the config like the cluster records.
+type MetadataStore map[string]Metadata
+
+// LoadMetadata reads the local metadata store. A missing file is not an error.
+func LoadMetadata() (MetadataStore, error) {
+	store := MetadataStore{}
+	return store, loadStore("metadata.json", &store)
+}
+
+// Save writes the metadata store back.
+func (m MetadataStore) Save() error {
+	return saveStore("metadata.json", m)
+}
+
+// Lookup returns the metadata of a cluster. Entries left by an earlier cluster with the same id are ignored.
+func (m MetadataStore) Lookup(c ClusterList) Metadata {
+	metadata, ok := m[strconv.Itoa(c.Id)]
+	if !ok || metadata.Alias != c.CustomerToken {
+		return Metadata{Alias: c.CustomerToken}
+	}
+	return metadata
+}
+
+// LabelKeys returns every label key used by the store, sorted.
+func (m MetadataStore) LabelKeys() []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, metadata := range m {
+		for key := range metadata.Labels {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Prune drops the entries of clusters which aren't in the list anymore, or whose id now belongs to another cluster, and
+// reports whether any were dropped.
+func (m MetadataStore) Prune(clusters []ClusterList) bool {
+	listed := map[string]string{}
+	for _, clusterData := range clusters {
+		listed[strconv.Itoa(clusterData.Id)] = clusterData.CustomerToken
+	}
+	pruned := false
+	for id, metadata := range m {
+		if alias, ok := listed[id]; !ok || alias != metadata.Alias {
+			delete(m, id)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+// PruneMetadata drops the metadata of clusters missing from a complete cluster list. An empty list is ignored, as it
+// is more likely a hiccup of the API than every cluster being gone. Only commands the user runs to list or label
+// clusters prune, not polling, so a cluster missing from one fetch doesn't lose its labels.
+func PruneMetadata(clusters []ClusterList) error {
+	if len(clusters) == 0 {
+		return nil
+	}
+	store, err := LoadMetadata()
+	if err != nil || !store.Prune(clusters) {
+		return err
+	}
+	return store.Save()
+}
+
+// MetadataChange is a parsed 'key=value' or 'key-' argument of 'cluster label' and 'cluster annotate'.
+type MetadataChange struct {
+	Key    string
+	Value  string
+	Remove bool
+}
+
+var (
+	// labelKey follows Kubernetes: an optional DNS prefix and a name of alphanumerics, '-', '_' and '.'.
+	labelKey   = regexp.MustCompile(`^([a-z0-9]([-a-z0-9.]*[a-z0-9])?/)?[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$`)
+	labelValue = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?)?$`)
+)
+
+// ParseMetadataChanges parses 'key=value' and 'key-' arguments. Labels must be valid Kubernetes labels and can't use
+// the keys of Attributes, as they would shadow them in selectors.
+func ParseMetadataChanges(kind string, args []string) ([]MetadataChange, error) {
+	var changes []MetadataChange
+	for _, arg := range args {
+		change := MetadataChange{}
+		if key, value, ok := strings.Cut(arg, "="); ok {
+			change.Key, change.Value = key, value
+		} else if strings.HasSuffix(arg, "-") {
+			change.Key, change.Remove = strings.TrimSuffix(arg, "-"), true
+		} else {
+			return nil, fmt.Errorf("invalid argument %q, use key=value to set or key- to remove", arg)
+		}
+		if change.Key == "" {
+			return nil, fmt.Errorf("invalid argument %q, the key is empty", arg)
+		}
+		if kind == MetadataLabels {
+			if !labelKey.MatchString(change.Key) {
+				return nil, fmt.Errorf("invalid label key %q, use alphanumerics, '-', '_' and '.' with an optional 'prefix/'", change.Key)
+			}
+			if _, reserved := Attributes(ClusterList{}, time.Time{})[change.Key]; reserved {
+				return nil, fmt.Errorf("label key %q is reserved for the built-in selector attribute", change.Key)
+			}
+			if !labelValue.MatchString(change.Value) {
+				return nil, fmt.Errorf("invalid label value %q, use at most 63 alphanumerics, '-', '_' and '.'", change.Value)
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+/*
+Apply sets and removes labels or annotations of a cluster. Changing the value of an existing key needs overwrite, like
+kubectl label.
+// The entry is dropped once it holds nothing, so the store only lists clusters with metadata.
+*/
+func (m MetadataStore) Apply(c ClusterList, kind string, changes []MetadataChange, overwrite bool) error {
+	metadata := m.Lookup(c)
+	values := map[string]string{}
+	for key, value := range metadata.Get(kind) {
+		values[key] = value
+	}
+	for _, change := range changes {
+		current, exists := values[change.Key]
+		switch {
+		case change.Remove:
+			delete(values, change.Key)
+		case exists && current != change.Value && !overwrite:
+			return fmt.Errorf("cluster %s already has %s %q set to %q, use --overwrite to change it", c.CustomerToken, strings.TrimSuffix(kind, "s"), change.Key, current)
+		default:
+			values[change.Key] = change.Value
+		}
+	}
+	if len(values) == 0 {
+		values = nil
+	}
+	if kind == MetadataAnnotations {
+		metadata.Annotations = values
+	} else {
+		metadata.Labels = values
+	}
+
+	id := strconv.Itoa(c.Id)
+	if len(metadata.Labels) == 0 && len(metadata.Annotations) == 0 {
+		delete(m, id)
+	} else {
+		m[id] = metadata
+	}
+	return nil
+}
+
+// FormatMetadata renders labels or annotations as 'key=value' pairs sorted by key, or "-" when there are none.
+func FormatMetadata(values map[string]string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	pairs := make([]string, 0, len(values))
+	for key, value := range values {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
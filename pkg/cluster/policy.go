@@ -0,0 +1,170 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/config"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Policy limits the clusters roost launches, before they are requested. It is read from policy.yaml next to the config,
+so every context has its own, and from the policy_file setting, which may point to a policy shipped by the team. When
+both exist, the stricter of their limits applies. Zero limits and an empty list of instance types don't limit.
+*/
+type Policy struct {
+	// MaxActiveClusters is how many running or requested clusters each customer email may have.
+	MaxActiveClusters    int      `yaml:"max_active_clusters"`
+	MaxWorkerNodes       int      `yaml:"max_workers"`
+	MaxExpiryHours       int      `yaml:"max_expiry_hours"`
+	AllowedInstanceTypes []string `yaml:"allowed_instance_types"`
+	// Sources are the files the policy was read from.
+	Sources []string `yaml:"-"`
+}
+
+// LoadPolicy reads the policy files. Without any, the policy is empty.
+func LoadPolicy() (Policy, error) {
+	var policy Policy
+	dir, err := config.Dir()
+	if err != nil {
+		return policy, err
+	}
+	paths := []string{filepath.Join(dir, "policy.yaml")}
+	if shared := viper.GetString("policy_file"); shared != "" {
+		paths = append(paths, shared)
+	}
+	for i, path := range paths {
+		part, err := readPolicy(path)
+		// Only the policy of the context is optional, a missing team policy shouldn't silently lift its limits.
+		if errors.Is(err, os.ErrNotExist) && i == 0 {
+			continue
+		}
+		if err != nil {
+			return policy, err
+		}
+		if policy, err = policy.merge(part); err != nil {
+			return policy, fmt.Errorf("the policies %s and %s %s", strings.Join(policy.Sources, ", "), path, err.Error())
+		}
+		policy.Sources = append(policy.Sources, path)
+	}
+	return policy, nil
+}
+
+func readPolicy(path string) (Policy, error) {
+	var policy Policy
+	f, err := os.Open(path)
+	if err != nil {
+		return policy, err
+	}
+	defer f.Close()
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil && !errors.Is(err, io.EOF) {
+		return policy, fmt.Errorf("invalid policy file %s: %s", path, err.Error())
+	}
+	return policy, nil
+}
+
+// merge returns the stricter limits of both policies.
+func (p Policy) merge(other Policy) (Policy, error) {
+	for _, limit := range []struct{ to, from *int }{
+		{&p.MaxActiveClusters, &other.MaxActiveClusters},
+		{&p.MaxWorkerNodes, &other.MaxWorkerNodes},
+		{&p.MaxExpiryHours, &other.MaxExpiryHours},
+	} {
+		if *limit.from > 0 && (*limit.to <= 0 || *limit.from < *limit.to) {
+			*limit.to = *limit.from
+		}
+	}
+	switch {
+	case len(other.AllowedInstanceTypes) == 0:
+	case len(p.AllowedInstanceTypes) == 0:
+		p.AllowedInstanceTypes = other.AllowedInstanceTypes
+	default:
+		var both []string
+		for _, instanceType := range p.AllowedInstanceTypes {
+			if contains(other.AllowedInstanceTypes, instanceType) {
+				both = append(both, instanceType)
+			}
+		}
+		if len(both) == 0 {
+			return p, fmt.Errorf("have no allowed instance type in common")
+		}
+		p.AllowedInstanceTypes = both
+	}
+	return p, nil
+}
+
+// IsEmpty reports whether the policy doesn't limit anything.
+func (p Policy) IsEmpty() bool {
+	return p.MaxActiveClusters <= 0 && p.MaxWorkerNodes <= 0 && p.MaxExpiryHours <= 0 && len(p.AllowedInstanceTypes) == 0
+}
+
+/*
+Check returns how launching the requests would break the policy, given the current clusters. The requests count
+towards the active clusters of their email in order, so launching several at once can't get around the limit.
+*/
+func (p Policy) Check(requests []CreateClusterRequest, clusters []ClusterList) []string {
+	active := map[string]int{}
+	for _, clusterData := range clusters {
+		if status := Status(clusterData); status == StatusRunning || status == StatusInProgress {
+			active[strings.ToLower(clusterData.CustomerEmail)]++
+		}
+	}
+	var violations []string
+	for _, request := range requests {
+		violate := func(format string, args ...any) {
+			violations = append(violations, fmt.Sprintf("cluster %s: ", request.Alias)+fmt.Sprintf(format, args...))
+		}
+		if p.MaxWorkerNodes > 0 && request.WorkerNodes > p.MaxWorkerNodes {
+			violate("%d worker nodes, the policy allows at most %d", request.WorkerNodes, p.MaxWorkerNodes)
+		}
+		if p.MaxExpiryHours > 0 && request.ClusterExpiry > p.MaxExpiryHours {
+			violate("an expiry of %d hours, the policy allows at most %d", request.ClusterExpiry, p.MaxExpiryHours)
+		}
+		if len(p.AllowedInstanceTypes) > 0 && !contains(p.AllowedInstanceTypes, request.InstanceType) {
+			violate("instance type %s, the policy allows %s", request.InstanceType, strings.Join(p.AllowedInstanceTypes, ", "))
+		}
+		email := strings.ToLower(request.Email)
+		active[email]++
+		if p.MaxActiveClusters > 0 && active[email] > p.MaxActiveClusters {
+			violate("%s would have %d active clusters, the policy allows at most %d", request.Email, active[email], p.MaxActiveClusters)
+		}
+	}
+	return violations
+}
+
+// PolicyOverride is an entry of the log of launches which went past the policy with --override-policy.
+type PolicyOverride struct {
+	Time       time.Time `json:"time"`
+	User       string    `json:"user"`
+	Command    string    `json:"command"`
+	Clusters   []string  `json:"clusters"`
+	Violations []string  `json:"violations"`
+}
+
+// RecordPolicyOverride appends an override to the log next to the config and returns the path of the log.
+func RecordPolicyOverride(override PolicyOverride) (string, error) {
+	if override.User == "" {
+		if current, err := user.Current(); err == nil {
+			override.User = current.Username
+		}
+	}
+	var overrides []PolicyOverride
+	if err := loadStore("policy-overrides.json", &overrides); err != nil {
+		return "", err
+	}
+	overrides = append(overrides, override)
+	if err := saveStore("policy-overrides.json", overrides); err != nil {
+		return "", err
+	}
+	return storePath("policy-overrides.json")
+}
@@ -0,0 +1,250 @@
+package cluster
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ZB-io/internal/roostcli/pkg/config"
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// presetName is what preset names may look like, so they make plain file names.
+var presetName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Preset is a saved shape of cluster. Fields which are empty in the file are not set by the preset.
+type Preset struct {
+	Name    string
+	Path    string
+	Request CreateClusterRequest
+}
+
+// PresetDirs returns the directories presets are looked up in: presets next to the config, where presets are saved,
+// followed by the directories of the preset_path setting, separated like $PATH, which may hold presets shared by a team.
+func PresetDirs() ([]string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	dirs := []string{filepath.Join(dir, "presets")}
+	for _, shared := range filepath.SplitList(viper.GetString("preset_path")) {
+		if shared != "" {
+			dirs = append(dirs, shared)
+		}
+	}
+	return dirs, nil
+}
+
+// CheckPresetName reports names which wouldn't make a plain file name.
+func CheckPresetName(name string) error {
+	if !presetName.MatchString(name) {
+		return fmt.Errorf("invalid preset name %q, use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// isPresetFile reports whether a preset is referred to by the path of its file rather than its name.
+func isPresetFile(nameOrPath string) bool {
+	ext := filepath.Ext(nameOrPath)
+	return strings.ContainsRune(nameOrPath, filepath.Separator) || ext == ".yaml" || ext == ".yml" || ext == ".json"
+}
+
+/*
+FindPreset returns the preset with the given name, from the first of PresetDirs holding it. A path to a preset file,
+such as one checked into a repository, can be given instead of a name.
+*/
+func FindPreset(nameOrPath string) (Preset, error) {
+	if isPresetFile(nameOrPath) {
+		return ReadPreset(nameOrPath)
+	}
+	if err := CheckPresetName(nameOrPath); err != nil {
+		return Preset{}, err
+	}
+	dirs, err := PresetDirs()
+	if err != nil {
+		return Preset{}, err
+	}
+	for _, dir := range dirs {
+		for _, ext := range []string{".yaml", ".yml", ".json"} {
+			preset, err := ReadPreset(filepath.Join(dir, nameOrPath+ext))
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return preset, err
+		}
+	}
+
+	var names []string
+	presets, _ := ListPresets()
+	for _, preset := range presets {
+		names = append(names, preset.Name)
+	}
+	if len(names) == 0 {
+		return Preset{}, fmt.Errorf("no preset named %q, save one with 'roost cluster preset save'", nameOrPath)
+	}
+	return Preset{}, fmt.Errorf("no preset named %q, the presets are %s", nameOrPath, strings.Join(names, ", "))
+}
+
+// ReadPreset reads a preset file. Its keys are those of cluster spec files, and unknown keys are reported.
+func ReadPreset(path string) (Preset, error) {
+	preset := Preset{Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), Path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return preset, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return preset, SpecErrors{{Source: path, Reason: err.Error()}}
+	}
+	if len(doc.Content) == 0 {
+		return preset, nil
+	}
+	if errs := checkSpecKeys(path, doc.Content[0]); len(errs) > 0 {
+		return preset, errs
+	}
+	if err := doc.Content[0].Decode(&preset.Request); err != nil {
+		return preset, SpecErrors{{Source: path, Reason: err.Error()}}
+	}
+	return preset, nil
+}
+
+// ListPresets returns the presets of PresetDirs sorted by name. A preset hides those of the same name in later directories.
+func ListPresets() ([]Preset, error) {
+	dirs, err := PresetDirs()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var presets []Preset
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+				continue
+			}
+			preset, err := ReadPreset(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			if !seen[preset.Name] {
+				seen[preset.Name] = true
+				presets = append(presets, preset)
+			}
+		}
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets, nil
+}
+
+// MarshalPreset renders a request as a preset file, leaving out the fields which aren't set.
+func MarshalPreset(name string, request CreateClusterRequest) ([]byte, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, HeadComment: "roost cluster preset " + name + ", use it with 'roost cluster create --preset " + name + "'"}
+	value := reflect.ValueOf(request)
+	for i := 0; i < value.NumField(); i++ {
+		key := specKeyOf(value.Type().Field(i))
+		if key == "" || key == "-" || value.Field(i).IsZero() {
+			continue
+		}
+		var item yaml.Node
+		if err := item.Encode(value.Field(i).Interface()); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &item)
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), encoder.Close()
+}
+
+// SavePreset writes a preset into the first of PresetDirs and returns its path. An existing preset is only replaced
+// with overwrite.
+func SavePreset(name string, request CreateClusterRequest, overwrite bool) (string, error) {
+	if err := CheckPresetName(name); err != nil {
+		return "", err
+	}
+	dirs, err := PresetDirs()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dirs[0], name+".yaml")
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return "", fmt.Errorf("preset %s already exists, use --overwrite to replace it", name)
+	}
+	data, err := MarshalPreset(name, request)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dirs[0], 0755); err != nil {
+		return "", err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", err
+	}
+	return path, os.Rename(tmp, path)
+}
+
+// DeletePreset removes a saved preset. Presets of the shared directories are left alone.
+func DeletePreset(name string) (string, error) {
+	if err := CheckPresetName(name); err != nil {
+		return "", err
+	}
+	dirs, err := PresetDirs()
+	if err != nil {
+		return "", err
+	}
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		path := filepath.Join(dirs[0], name+ext)
+		if err := os.Remove(path); !errors.Is(err, os.ErrNotExist) {
+			return path, err
+		}
+	}
+	if preset, err := FindPreset(name); err == nil {
+		return "", fmt.Errorf("preset %s is shared from %s and can only be removed there", name, preset.Path)
+	}
+	return "", fmt.Errorf("no saved preset named %q", name)
+}
+
+// Validate reports the values of the preset which no cluster could be created with. Fields the preset doesn't set
+// are left to the flags or the form.
+func (p Preset) Validate() error {
+	failures, err := utils.Validate(&p.Request)
+	if err != nil {
+		return err
+	}
+	source := p.Path
+	if source == "" {
+		source = "preset " + p.Name
+	}
+	value := reflect.ValueOf(p.Request)
+	var errs SpecErrors
+	for _, failure := range failures {
+		if value.FieldByIndex(failure.Field.Index).IsZero() {
+			continue
+		}
+		errs = append(errs, SpecError{Source: source, Key: specKeyOf(failure.Field), Reason: failure.Message})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
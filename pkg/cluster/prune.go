@@ -0,0 +1,131 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+PrunePolicy picks the clusters 'cluster prune' deletes. A cluster is a candidate when it matches any of the states,
+stopped for long enough, failed or expired, and all of the filters. Clusters with one of the keep labels are never
+pruned.
+// The yaml keys are those of policy files, so cron can run a policy kept next to the crontab.
+*/
+type PrunePolicy struct {
+	// StoppedOlderThan is a duration such as 24h or 7d, empty to leave stopped clusters alone.
+	StoppedOlderThan string `yaml:"stopped_older_than"`
+	Failed           bool   `yaml:"failed"`
+	Expired          bool   `yaml:"expired"`
+	// Email is a glob of customer emails, or "me" for the email setting.
+	Email string `yaml:"email"`
+	// KeepLabels are key=value pairs, or bare keys matching any value.
+	KeepLabels    []string `yaml:"keep_labels"`
+	Selector      string   `yaml:"selector"`
+	FieldSelector string   `yaml:"field_selector"`
+}
+
+// ReadPrunePolicy reads a policy file. Unknown keys are errors, so a typo doesn't widen what is deleted.
+func ReadPrunePolicy(path string) (PrunePolicy, error) {
+	var policy PrunePolicy
+	f, err := os.Open(path)
+	if err != nil {
+		return policy, err
+	}
+	defer f.Close()
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil {
+		return policy, fmt.Errorf("invalid prune policy %s: %s", path, err.Error())
+	}
+	return policy, nil
+}
+
+// PruneCandidate is a cluster the policy would delete, with why.
+type PruneCandidate struct {
+	Cluster ClusterList
+	Reason  string
+}
+
+// Candidates returns the clusters the policy would delete.
+func (p PrunePolicy) Candidates(clusters []ClusterList, records Records, metadata MetadataStore, now time.Time) ([]PruneCandidate, error) {
+	if p.StoppedOlderThan == "" && !p.Failed && !p.Expired {
+		return nil, fmt.Errorf("no clusters to prune, use --stopped-older-than, --failed or --expired")
+	}
+	var stoppedFor time.Duration
+	if p.StoppedOlderThan != "" {
+		var err error
+		if stoppedFor, err = utils.ParseDuration(p.StoppedOlderThan); err != nil {
+			return nil, err
+		}
+	}
+	filter := ListFilter{Email: p.Email}
+	if p.Email == "me" {
+		if filter.Email = viper.GetString("email"); filter.Email == "" {
+			return nil, fmt.Errorf("--email me needs your email in the email setting of the config")
+		}
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	clusters, err := Select(filter.Filter(clusters), p.Selector, p.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []PruneCandidate
+	for _, clusterData := range clusters {
+		if p.kept(metadata.Lookup(clusterData).Labels) {
+			continue
+		}
+		reason := ""
+		switch Status(clusterData) {
+		case StatusStopped:
+			if p.StoppedOlderThan == "" {
+				continue
+			}
+			stoppedOn, err := utils.ParseTime(clusterData.StoppedOn)
+			if err != nil {
+				// Without a stop time it may have stopped a minute ago, whenever it was created.
+				continue
+			}
+			if now.Sub(stoppedOn) < stoppedFor {
+				continue
+			}
+			reason = "stopped " + utils.Ago(stoppedOn, now)
+		case StatusFailed:
+			if !p.Failed {
+				continue
+			}
+			reason = "failed"
+			if clusterData.FailureMsg != "" {
+				reason += ": " + clusterData.FailureMsg
+			}
+		case StatusRunning, StatusInProgress:
+			left, ok := records.ExpiresIn(clusterData, now)
+			if !p.Expired || !ok || left > 0 {
+				continue
+			}
+			reason = "expired " + utils.Ago(now.Add(left), now)
+		default:
+			continue
+		}
+		candidates = append(candidates, PruneCandidate{Cluster: clusterData, Reason: reason})
+	}
+	return candidates, nil
+}
+
+func (p PrunePolicy) kept(labels map[string]string) bool {
+	for _, keep := range p.KeepLabels {
+		key, value, hasValue := strings.Cut(keep, "=")
+		if current, ok := labels[key]; ok && (!hasValue || current == value) {
+			return true
+		}
+	}
+	return false
+}
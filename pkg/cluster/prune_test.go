@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+	clusters := []ClusterList{
+		{Id: 1, CustomerToken: "old", StatusMsg: "Stopped", CreatedOn: at(96 * time.Hour), StoppedOn: at(72 * time.Hour)},
+		{Id: 2, CustomerToken: "recent", StatusMsg: "Stopped", CreatedOn: at(96 * time.Hour), StoppedOn: at(time.Hour)},
+		// Created long ago, but it may have stopped a minute ago.
+		{Id: 3, CustomerToken: "no-stop-time", StatusMsg: "Stopped", CreatedOn: at(72 * time.Hour)},
+		{Id: 4, CustomerToken: "kept", StatusMsg: "Stopped", StoppedOn: at(72 * time.Hour)},
+		{Id: 5, CustomerToken: "broken", FailureMsg: "no capacity"},
+		{Id: 6, CustomerToken: "expired", IsActive: true, RunningOn: at(3 * time.Hour)},
+		{Id: 7, CustomerToken: "alive", IsActive: true, RunningOn: at(time.Hour)},
+		// Created elsewhere, so its expiry is unknown.
+		{Id: 8, CustomerToken: "elsewhere", IsActive: true, RunningOn: at(72 * time.Hour)},
+	}
+	records := Records{
+		"expired": {Alias: "expired", Request: CreateClusterRequest{ClusterExpiry: 2}},
+		"alive":   {Alias: "alive", Request: CreateClusterRequest{ClusterExpiry: 2}},
+	}
+	metadata := MetadataStore{
+		"4": {Alias: "kept", Labels: map[string]string{"keep": "yes"}},
+		// Left by an earlier cluster with the id of old.
+		"1": {Alias: "gone", Labels: map[string]string{"keep": "yes"}},
+	}
+
+	tests := []struct {
+		name   string
+		policy PrunePolicy
+		want   map[string]string
+	}{
+		{
+			name:   "stopped",
+			policy: PrunePolicy{StoppedOlderThan: "24h"},
+			want:   map[string]string{"old": "stopped 3d0h ago", "kept": "stopped 3d0h ago"},
+		},
+		{
+			name:   "keep label",
+			policy: PrunePolicy{StoppedOlderThan: "1d", KeepLabels: []string{"keep"}},
+			want:   map[string]string{"old": "stopped 3d0h ago"},
+		},
+		{
+			name:   "keep label with another value",
+			policy: PrunePolicy{StoppedOlderThan: "1d", KeepLabels: []string{"keep=no"}},
+			want:   map[string]string{"old": "stopped 3d0h ago", "kept": "stopped 3d0h ago"},
+		},
+		{
+			name:   "failed",
+			policy: PrunePolicy{Failed: true},
+			want:   map[string]string{"broken": "failed: no capacity"},
+		},
+		{
+			name:   "expired",
+			policy: PrunePolicy{Expired: true},
+			want:   map[string]string{"expired": "expired 1h0m ago"},
+		},
+	}
+	for _, tt := range tests {
+		candidates, err := tt.policy.Candidates(clusters, records, metadata, now)
+		if err != nil {
+			t.Errorf("%s: Candidates() error = %v", tt.name, err)
+			continue
+		}
+		got := map[string]string{}
+		for _, candidate := range candidates {
+			got[candidate.Cluster.CustomerToken] = candidate.Reason
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: candidates = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := (PrunePolicy{Email: "*@ourco.com"}).Candidates(clusters, records, metadata, now); err == nil {
+		t.Error("Candidates() of a policy without states picked clusters instead of failing")
+	}
+	if _, err := (PrunePolicy{StoppedOlderThan: "soon"}).Candidates(clusters, records, metadata, now); err == nil {
+		t.Error("Candidates() accepted an invalid duration")
+	}
+}
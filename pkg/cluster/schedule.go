@@ -0,0 +1,201 @@
+package cluster
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/cron"
+)
+
+// Actions of schedule rules.
+const (
+	ScheduleStop  = "stop"
+	ScheduleStart = "start"
+)
+
+// maxScheduleHistory is how many runs the history keeps, dropping the oldest.
+const maxScheduleHistory = 1000
+
+var scheduleName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+/*
+ScheduleRule stops or starts the clusters matching its selectors whenever its cron expression fires, in its time zone.
+An empty time zone is that of the machine running 'roost schedule run'.
+// A rule without selectors applies to all clusters, which has to be asked for with All.
+*/
+type ScheduleRule struct {
+	Name          string    `json:"name"`
+	Action        string    `json:"action"`
+	Cron          string    `json:"cron"`
+	TimeZone      string    `json:"time_zone,omitempty"`
+	Selector      string    `json:"selector,omitempty"`
+	FieldSelector string    `json:"field_selector,omitempty"`
+	All           bool      `json:"all,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Validate reports rules which couldn't run.
+func (r ScheduleRule) Validate() error {
+	if !scheduleName.MatchString(r.Name) {
+		return fmt.Errorf("invalid schedule name %q, use letters, digits, '.', '_' and '-'", r.Name)
+	}
+	if r.Action != ScheduleStop && r.Action != ScheduleStart {
+		return fmt.Errorf("invalid action %q, use %s or %s", r.Action, ScheduleStop, ScheduleStart)
+	}
+	if _, err := cron.Parse(r.Cron); err != nil {
+		return err
+	}
+	if _, err := r.Location(); err != nil {
+		return err
+	}
+	all, err := SelectsAll(r.Selector, r.FieldSelector)
+	if err != nil {
+		return err
+	}
+	if all && !r.All {
+		return fmt.Errorf("schedule %s has no selector requirements, use --selector, --field-selector or --all", r.Name)
+	}
+	return nil
+}
+
+// Location returns the time zone the cron expression is read in.
+func (r ScheduleRule) Location() (*time.Location, error) {
+	if r.TimeZone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q, use a name such as Europe/Berlin or America/New_York", r.TimeZone)
+	}
+	return loc, nil
+}
+
+// Next returns the first time after the given one the rule fires, or the zero time if it never does.
+func (r ScheduleRule) Next(after time.Time) (time.Time, error) {
+	schedule, err := cron.Parse(r.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := r.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(after, loc), nil
+}
+
+// Targets returns the clusters the rule acts on now: the running ones it stops or the stopped ones it starts.
+func (r ScheduleRule) Targets(clusters []ClusterList) ([]ClusterList, error) {
+	selected, err := Select(clusters, r.Selector, r.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+	want := StatusRunning
+	if r.Action == ScheduleStart {
+		want = StatusStopped
+	}
+	var targets []ClusterList
+	for _, clusterData := range selected {
+		if Status(clusterData) == want {
+			targets = append(targets, clusterData)
+		}
+	}
+	return targets, nil
+}
+
+// Schedules are the schedule rules keyed by name.
+type Schedules map[string]ScheduleRule
+
+// LoadSchedules reads the schedule rules. A missing file is not an error.
+func LoadSchedules() (Schedules, error) {
+	schedules := Schedules{}
+	return schedules, loadStore("schedules.json", &schedules)
+}
+
+// Save writes the schedule rules back.
+func (s Schedules) Save() error {
+	return saveStore("schedules.json", s)
+}
+
+// Sorted returns the rules sorted by name.
+func (s Schedules) Sorted() []ScheduleRule {
+	rules := make([]ScheduleRule, 0, len(s))
+	for _, rule := range s {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// Firing is a time a rule fires.
+type Firing struct {
+	Rule ScheduleRule
+	At   time.Time
+}
+
+/*
+Due returns the rules firing after from and up to to, sorted by name. A rule firing several times in between is
+returned once, with its latest firing.
+*/
+func (s Schedules) Due(from, to time.Time) []Firing {
+	var due []Firing
+	for _, rule := range s.Sorted() {
+		var latest time.Time
+		for next, err := rule.Next(from); err == nil && !next.IsZero() && !next.After(to); next, err = rule.Next(next) {
+			latest = next
+		}
+		if !latest.IsZero() {
+			due = append(due, Firing{Rule: rule, At: latest})
+		}
+	}
+	return due
+}
+
+/*
+ScheduleRun is an entry of the schedule history: what a rule did to a cluster when it fired. A firing which matched no
+cluster, or couldn't list them, is recorded without one. A run which failed keeps its error.
+*/
+type ScheduleRun struct {
+	Rule    string    `json:"rule"`
+	Action  string    `json:"action"`
+	Time    time.Time `json:"time"`
+	Cluster string    `json:"cluster,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	DryRun  bool      `json:"dry_run,omitempty"`
+}
+
+// Outcome describes the run for the history and the log of 'roost schedule run'.
+func (r ScheduleRun) Outcome() string {
+	switch {
+	case r.Error != "":
+		return "failed: " + r.Error
+	case r.Cluster == "":
+		return "no matching clusters"
+	case r.DryRun:
+		return "would " + r.Action
+	case r.Action == ScheduleStop:
+		return "stopped"
+	}
+	return "started"
+}
+
+// LoadScheduleHistory reads the schedule runs, oldest first. A missing file is not an error.
+func LoadScheduleHistory() ([]ScheduleRun, error) {
+	var runs []ScheduleRun
+	err := loadStore("schedule-history.json", &runs)
+	return runs, err
+}
+
+// RecordScheduleRuns appends runs to the schedule history, keeping its latest entries.
+func RecordScheduleRuns(runs ...ScheduleRun) error {
+	history, err := LoadScheduleHistory()
+	if err != nil {
+		return err
+	}
+	history = append(history, runs...)
+	if len(history) > maxScheduleHistory {
+		history = history[len(history)-maxScheduleHistory:]
+	}
+	return saveStore("schedule-history.json", history)
+}
@@ -0,0 +1,37 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScheduleRuleValidate(t *testing.T) {
+	rule := ScheduleRule{Name: "nightly", Action: ScheduleStop, Cron: "0 19 * * mon-fri", TimeZone: "Europe/Berlin"}
+	tests := []struct {
+		name string
+		edit func(r *ScheduleRule)
+		err  string
+	}{
+		{"selector", func(r *ScheduleRule) { r.Selector = "env=dev" }, ""},
+		{"field selector", func(r *ScheduleRule) { r.FieldSelector = "is_active=true" }, ""},
+		{"all", func(r *ScheduleRule) { r.All = true }, ""},
+		{"no selector", func(r *ScheduleRule) {}, "no selector requirements"},
+		// Only commas parse to no requirements and would match every cluster.
+		{"empty selectors", func(r *ScheduleRule) { r.Selector, r.FieldSelector = ",", " , " }, "no selector requirements"},
+		{"invalid selector", func(r *ScheduleRule) { r.Selector = "env" }, "invalid requirement"},
+		{"invalid action", func(r *ScheduleRule) { r.Action, r.All = "delete", true }, "invalid action"},
+		{"invalid cron", func(r *ScheduleRule) { r.Cron, r.All = "0 25 * * *", true }, "invalid hour"},
+		{"invalid time zone", func(r *ScheduleRule) { r.TimeZone, r.All = "Mars/Olympus", true }, "unknown time zone"},
+	}
+	for _, tt := range tests {
+		r := rule
+		tt.edit(&r)
+		err := r.Validate()
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: Validate() error = %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: Validate() error = %v, want one containing %q", tt.name, err, tt.err)
+		}
+	}
+}
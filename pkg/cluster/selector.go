@@ -0,0 +1,155 @@
+package cluster
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/selector"
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+)
+
+// Cluster states derived by Status.
+const (
+	StatusRunning    = "running"
+	StatusStopped    = "stopped"
+	StatusFailed     = "failed"
+	StatusInProgress = "in-progress"
+	StatusUnknown    = "unknown"
+)
+
+// Status derives the state of a cluster from its list entry.
+func Status(c ClusterList) string {
+	message := strings.ToLower(c.StatusMsg)
+	switch {
+	case c.FailureMsg != "" || strings.Contains(message, "fail"):
+		return StatusFailed
+	case c.IsActive:
+		return StatusRunning
+	case strings.Contains(message, "stop"):
+		return StatusStopped
+	case strings.Contains(message, "progress"):
+		return StatusInProgress
+	}
+	return StatusUnknown
+}
+
+// Age returns how long ago the cluster was created, or false if the creation time is unknown.
+func Age(c ClusterList, now time.Time) (time.Duration, bool) {
+	created, err := utils.ParseTime(c.CreatedOn)
+	if err != nil {
+		return 0, false
+	}
+	return now.Sub(created), true
+}
+
+func ageField(c ClusterList, now time.Time) string {
+	if age, ok := Age(c, now); ok {
+		return age.Truncate(time.Second).String()
+	}
+	return ""
+}
+
+// Fields returns the fields of a cluster list entry keyed by their JSON names, plus its age, for --field-selector.
+func Fields(c ClusterList, now time.Time) map[string]string {
+	return map[string]string{
+		"id":              strconv.Itoa(c.Id),
+		"alias":           c.Alias,
+		"customer_email":  c.CustomerEmail,
+		"customer_token":  c.CustomerToken,
+		"created_on":      c.CreatedOn,
+		"running_on":      c.RunningOn,
+		"stopped_on":      c.StoppedOn,
+		"is_active":       strconv.FormatBool(c.IsActive),
+		"public_ip":       c.PublicIP,
+		"num_nodes":       strconv.Itoa(c.NumNodes),
+		"cluster_type":    c.ClusterType,
+		"env_type":        c.EnvType,
+		"failure_message": c.FailureMsg,
+		"status_message":  c.StatusMsg,
+		"age":             ageField(c, now),
+	}
+}
+
+// Attributes returns the short, derived attributes of a cluster used by --selector, e.g. status=failed,age>4h.
+func Attributes(c ClusterList, now time.Time) map[string]string {
+	return map[string]string{
+		"id":     strconv.Itoa(c.Id),
+		"alias":  c.CustomerToken,
+		"email":  c.CustomerEmail,
+		"status": Status(c),
+		"type":   c.ClusterType,
+		"env":    c.EnvType,
+		"nodes":  strconv.Itoa(c.NumNodes),
+		"ip":     c.PublicIP,
+		"age":    ageField(c, now),
+	}
+}
+
+/*
+LabeledAttributes returns Attributes with the local labels of the cluster added. Every label key used in the store is
+present, empty for clusters without it, so 'team!=web' matches unlabeled clusters rather than failing on an unknown key.
+*/
+func LabeledAttributes(c ClusterList, store MetadataStore, labelKeys []string, now time.Time) map[string]string {
+	attributes := Attributes(c, now)
+	labels := store.Lookup(c).Labels
+	for _, key := range labelKeys {
+		attributes[key] = labels[key]
+	}
+	return attributes
+}
+
+// SelectsAll reports whether the selector and the field selector have no requirements between them, such as when both
+// are empty or only commas, so they match every cluster.
+func SelectsAll(labelSelector, fieldSelector string) (bool, error) {
+	attributes, err := selector.Parse(labelSelector)
+	if err != nil {
+		return false, err
+	}
+	fields, err := selector.Parse(fieldSelector)
+	if err != nil {
+		return false, err
+	}
+	return len(attributes) == 0 && len(fields) == 0, nil
+}
+
+// Select returns the clusters matching both the selector, applied to LabeledAttributes, and the field selector,
+// applied to Fields.
+func Select(clusters []ClusterList, labelSelector, fieldSelector string) ([]ClusterList, error) {
+	attributes, err := selector.Parse(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := selector.Parse(fieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	store := MetadataStore{}
+	if len(attributes) > 0 {
+		if store, err = LoadMetadata(); err != nil {
+			return nil, err
+		}
+	}
+	labelKeys := store.LabelKeys()
+
+	now := time.Now()
+	var selected []ClusterList
+	for _, clusterData := range clusters {
+		ok, err := attributes.Matches(LabeledAttributes(clusterData, store, labelKeys, now))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		ok, err = fields.Matches(Fields(clusterData, now))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			selected = append(selected, clusterData)
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,109 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster ClusterList
+		want    string
+	}{
+		{"active", ClusterList{IsActive: true, StatusMsg: "Running"}, StatusRunning},
+		{"failure message wins", ClusterList{IsActive: true, FailureMsg: "no capacity"}, StatusFailed},
+		{"failed status", ClusterList{StatusMsg: "Launch Failed"}, StatusFailed},
+		{"stopped", ClusterList{StatusMsg: "Stopped ..."}, StatusStopped},
+		{"in progress", ClusterList{StatusMsg: "Request in Progress ..."}, StatusInProgress},
+		{"unknown", ClusterList{StatusMsg: "Hibernating"}, StatusUnknown},
+	}
+	for _, tt := range tests {
+		if got := Status(tt.cluster); got != tt.want {
+			t.Errorf("%s: Status() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAge(t *testing.T) {
+	now := time.Date(2023, 5, 10, 12, 0, 0, 0, time.UTC)
+	if age, ok := Age(ClusterList{CreatedOn: "2023-05-10T08:00:00.000Z"}, now); !ok || age != 4*time.Hour {
+		t.Errorf("Age() = %v, %v, want 4h", age, ok)
+	}
+	if _, ok := Age(ClusterList{CreatedOn: "yesterday"}, now); ok {
+		t.Error("Age() of an unparsable time succeeded")
+	}
+}
+
+func TestLabeledAttributes(t *testing.T) {
+	store := MetadataStore{
+		"1": {Alias: "web", Labels: map[string]string{"team": "checkout"}},
+		// Left by an earlier cluster with the same id.
+		"2": {Alias: "old", Labels: map[string]string{"team": "search"}},
+	}
+	keys := store.LabelKeys()
+	now := time.Now()
+	if got := LabeledAttributes(ClusterList{Id: 1, CustomerToken: "web"}, store, keys, now)["team"]; got != "checkout" {
+		t.Errorf("team of web = %q, want checkout", got)
+	}
+	attributes := LabeledAttributes(ClusterList{Id: 2, CustomerToken: "db"}, store, keys, now)
+	if got, ok := attributes["team"]; !ok || got != "" {
+		t.Errorf("team of db = %q, %v, want an empty label", got, ok)
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	clusters := []ClusterList{
+		{Id: 1, CustomerToken: "web", IsActive: true, NumNodes: 3, ClusterType: "roost"},
+		{Id: 2, CustomerToken: "db", NumNodes: 1, StatusMsg: "Stopped"},
+		{Id: 3, CustomerToken: "ci-build", IsActive: true, NumNodes: 1, ClusterType: "managed"},
+	}
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"", []string{"web", "db", "ci-build"}},
+		{"is_active=true", []string{"web", "ci-build"}},
+		{"is_active=true,num_nodes>1", []string{"web"}},
+		{"customer_token=ci-*", []string{"ci-build"}},
+		{"cluster_type!=roost", []string{"db", "ci-build"}},
+	}
+	for _, tt := range tests {
+		selected, err := Select(clusters, "", tt.selector)
+		if err != nil {
+			t.Fatalf("Select(%q) error = %v", tt.selector, err)
+		}
+		var got []string
+		for _, c := range selected {
+			got = append(got, c.CustomerToken)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Select(%q) = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+	if _, err := Select(clusters, "", "nodes>1"); err == nil {
+		t.Error("Select() with an unknown field succeeded")
+	}
+}
+
+func TestSelectsAll(t *testing.T) {
+	tests := []struct {
+		selector, fieldSelector string
+		want                    bool
+	}{
+		{"", "", true},
+		{",", " , ", true},
+		{"status=running", "", false},
+		{",", "is_active=true", false},
+	}
+	for _, tt := range tests {
+		got, err := SelectsAll(tt.selector, tt.fieldSelector)
+		if err != nil || got != tt.want {
+			t.Errorf("SelectsAll(%q, %q) = %v, %v, want %v", tt.selector, tt.fieldSelector, got, err, tt.want)
+		}
+	}
+	if _, err := SelectsAll("status", ""); err == nil {
+		t.Error("SelectsAll() accepted an invalid selector")
+	}
+}
@@ -0,0 +1,256 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ZB-io/internal/roostcli/pkg/resolve"
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// SpecError is a problem found in a cluster spec file.
+type SpecError struct {
+	Source string
+	Line   int
+	Key    string
+	Reason string
+}
+
+func (e SpecError) Error() string {
+	msg := e.Source
+	if e.Line > 0 {
+		msg += fmt.Sprintf(":%d", e.Line)
+	}
+	if e.Key != "" {
+		msg += ": " + e.Key
+	}
+	return msg + ": " + e.Reason
+}
+
+// SpecErrors are all the problems found in the spec files, so they can be fixed in one go.
+type SpecErrors []SpecError
+
+func (e SpecErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// specKeys maps the keys of a spec file to the CreateClusterRequest fields they set.
+func specKeys() map[string]reflect.StructField {
+	keys := map[string]reflect.StructField{}
+	t := reflect.TypeOf(CreateClusterRequest{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if key := field.Tag.Get("yaml"); key != "" && key != "-" {
+			keys[key] = field
+		}
+	}
+	return keys
+}
+
+func specKeyOf(field reflect.StructField) string {
+	return field.Tag.Get("yaml")
+}
+
+/*
+ParseSpecs reads cluster specs from YAML or JSON. source names the input in error messages.
+// A document may be a single cluster, a list of clusters, or a mapping with a clusters list and optional defaults
+// which apply to every cluster in it. Several documents can be separated by ---.
+//
+//	defaults:
+//	  email: ci@ourco.com
+//	  region: us-east-1
+//	clusters:
+//	  - alias: ci-${BUILD_ID}
+//	    workers: 2
+//
+// Environment variables are expanded first, see utils.ExpandEnv. Keys which aren't set take their value from defaults.
+// Unknown keys, values of the wrong type and values failing the validate tags of CreateClusterRequest are all reported.
+*/
+func ParseSpecs(source string, r io.Reader, defaults CreateClusterRequest) ([]CreateClusterRequest, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text, err := utils.ExpandEnv(string(data))
+	if err != nil {
+		return nil, SpecErrors{{Source: source, Reason: err.Error()}}
+	}
+
+	var specs []CreateClusterRequest
+	var errs SpecErrors
+	decoder := yaml.NewDecoder(strings.NewReader(text))
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, SpecErrors{{Source: source, Reason: err.Error()}}
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		parsed, docErrs := parseSpecDocument(source, doc.Content[0], defaults)
+		specs = append(specs, parsed...)
+		errs = append(errs, docErrs...)
+	}
+
+	if len(specs) == 0 && len(errs) == 0 {
+		errs = append(errs, SpecError{Source: source, Reason: "no clusters found"})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return specs, nil
+}
+
+func parseSpecDocument(source string, root *yaml.Node, defaults CreateClusterRequest) ([]CreateClusterRequest, SpecErrors) {
+	switch root.Kind {
+	case yaml.SequenceNode:
+		return parseSpecList(source, root, defaults)
+
+	case yaml.MappingNode:
+		var clusters, fileDefaults *yaml.Node
+		isFleet := false
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			switch root.Content[i].Value {
+			case "clusters":
+				clusters, isFleet = root.Content[i+1], true
+			case "defaults":
+				fileDefaults, isFleet = root.Content[i+1], true
+			}
+		}
+		if !isFleet {
+			spec, errs := parseSpec(source, root, defaults)
+			return []CreateClusterRequest{spec}, errs
+		}
+
+		var errs SpecErrors
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if key := root.Content[i]; key.Value != "clusters" && key.Value != "defaults" {
+				errs = append(errs, SpecError{Source: source, Line: key.Line, Key: key.Value, Reason: "unknown key, expected clusters or defaults"})
+			}
+		}
+		if fileDefaults != nil {
+			// Defaults are checked for unknown keys and types, but not validated, as clusters may fill in the rest.
+			errs = append(errs, checkSpecKeys(source, fileDefaults)...)
+			if err := fileDefaults.Decode(&defaults); err != nil {
+				errs = append(errs, SpecError{Source: source, Line: fileDefaults.Line, Key: "defaults", Reason: err.Error()})
+			}
+		}
+		if clusters == nil || clusters.Kind != yaml.SequenceNode {
+			return nil, append(errs, SpecError{Source: source, Line: root.Line, Key: "clusters", Reason: "must be a list of clusters"})
+		}
+		specs, listErrs := parseSpecList(source, clusters, defaults)
+		return specs, append(errs, listErrs...)
+	}
+	return nil, SpecErrors{{Source: source, Line: root.Line, Reason: "expected a cluster, a list of clusters, or clusters and defaults"}}
+}
+
+func parseSpecList(source string, list *yaml.Node, defaults CreateClusterRequest) ([]CreateClusterRequest, SpecErrors) {
+	var specs []CreateClusterRequest
+	var errs SpecErrors
+	for _, item := range list.Content {
+		spec, specErrs := parseSpec(source, item, defaults)
+		specs = append(specs, spec)
+		errs = append(errs, specErrs...)
+	}
+	return specs, errs
+}
+
+func parseSpec(source string, node *yaml.Node, defaults CreateClusterRequest) (CreateClusterRequest, SpecErrors) {
+	spec := defaults
+	if node.Kind != yaml.MappingNode {
+		return spec, SpecErrors{{Source: source, Line: node.Line, Reason: "a cluster must be a mapping of keys to values"}}
+	}
+	errs := checkSpecKeys(source, node)
+	if err := node.Decode(&spec); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			for _, reason := range typeErr.Errors {
+				// yaml reports "line 3: cannot unmarshal ...", the line goes where the other errors have it.
+				specErr := SpecError{Source: source, Line: node.Line, Reason: reason}
+				if line, rest, ok := strings.Cut(reason, ": "); ok {
+					if _, err := fmt.Sscanf(line, "line %d", &specErr.Line); err == nil {
+						specErr.Reason = rest
+					}
+				}
+				errs = append(errs, specErr)
+			}
+		} else {
+			errs = append(errs, SpecError{Source: source, Line: node.Line, Reason: err.Error()})
+		}
+		return spec, errs
+	}
+
+	failures, err := utils.Validate(&spec)
+	if err != nil {
+		return spec, append(errs, SpecError{Source: source, Line: node.Line, Reason: err.Error()})
+	}
+	for _, failure := range failures {
+		errs = append(errs, SpecError{Source: source, Line: specKeyLine(node, failure.Field), Key: specKeyOf(failure.Field), Reason: failure.Message})
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	return spec, errs
+}
+
+// checkSpecKeys reports keys of a cluster mapping which don't set any field.
+func checkSpecKeys(source string, node *yaml.Node) SpecErrors {
+	if node.Kind != yaml.MappingNode {
+		return SpecErrors{{Source: source, Line: node.Line, Reason: "must be a mapping of keys to values"}}
+	}
+	keys := specKeys()
+	var errs SpecErrors
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if _, ok := keys[key.Value]; ok {
+			continue
+		}
+		var names []string
+		for name := range keys {
+			names = append(names, name)
+		}
+		reason := "unknown key"
+		if suggestions := resolve.Suggest(key.Value, names, 1); len(suggestions) > 0 {
+			reason += ", did you mean " + suggestions[0] + "?"
+		}
+		errs = append(errs, SpecError{Source: source, Line: key.Line, Key: key.Value, Reason: reason})
+	}
+	return errs
+}
+
+// specKeyLine returns the line a field is set on, or the line of the cluster when it came from the defaults.
+func specKeyLine(node *yaml.Node, field reflect.StructField) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == specKeyOf(field) {
+			return node.Content[i].Line
+		}
+	}
+	return node.Line
+}
+
+// CheckDuplicateAliases reports aliases used by more than one spec.
+func CheckDuplicateAliases(specs []CreateClusterRequest) error {
+	seen := map[string]bool{}
+	var duplicates []string
+	for _, spec := range specs {
+		if seen[spec.Alias] {
+			duplicates = append(duplicates, spec.Alias)
+		}
+		seen[spec.Alias] = true
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("aliases used by more than one cluster: %s", strings.Join(duplicates, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/config"
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+)
+
+// Record is what roost remembers locally about a cluster it created, as the API doesn't return the request or the expiry.
+type Record struct {
+	Alias     string               `json:"alias"`
+	Request   CreateClusterRequest `json:"request"`
+	CreatedAt time.Time            `json:"created_at"`
+	// ExtendedHours is how much 'cluster extend' added to the requested expiry.
+	ExtendedHours int    `json:"extended_hours,omitempty"`
+	Fleet         string `json:"fleet,omitempty"`
+}
+
+// ExpiryHours is how long the cluster lives after it starts running.
+func (r Record) ExpiryHours() int {
+	return r.Request.ClusterExpiry + r.ExtendedHours
+}
+
+// ExpiresAt returns when the cluster expires, counting from when it last started running, or from its creation
+// while it hasn't run yet. It returns false if the expiry isn't known.
+func (r Record) ExpiresAt(c ClusterList) (time.Time, bool) {
+	if r.ExpiryHours() <= 0 {
+		return time.Time{}, false
+	}
+	started := r.CreatedAt
+	if runningOn, err := utils.ParseTime(c.RunningOn); err == nil {
+		started = runningOn
+	}
+	if started.IsZero() {
+		return time.Time{}, false
+	}
+	return started.Add(time.Duration(r.ExpiryHours()) * time.Hour), true
+}
+
+// Records are keyed by cluster alias.
+type Records map[string]Record
+
+// storePath returns the path of a local store, next to the config so every config keeps its own.
+func storePath(name string) (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// loadStore reads a local store into v. A missing file leaves v as it is.
+func loadStore(name string, v any) error {
+	path, err := storePath(name)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// saveStore writes a local store, replacing the file so a crash never leaves it half written.
+func saveStore(name string, v any) error {
+	path, err := storePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadRecords reads the local cluster records. A missing file is not an error.
+func LoadRecords() (Records, error) {
+	records := Records{}
+	return records, loadStore("clusters.json", &records)
+}
+
+// Save writes the records back.
+func (r Records) Save() error {
+	return saveStore("clusters.json", r)
+}
+
+// RecordCreate remembers the request a cluster was created with. The auth token is never stored.
+func RecordCreate(request CreateClusterRequest, createdAt time.Time) error {
+	records, err := LoadRecords()
+	if err != nil {
+		return err
+	}
+	request.RoostAuthToken = ""
+	records[request.Alias] = Record{Alias: request.Alias, Request: request, CreatedAt: createdAt}
+	return records.Save()
+}
+
+// Lookup returns the record of a cluster list entry.
+func (r Records) Lookup(c ClusterList) (Record, bool) {
+	if record, ok := r[c.CustomerToken]; ok {
+		return record, true
+	}
+	record, ok := r[c.Alias]
+	return record, ok
+}
+
+// ExpiresIn returns the time left before a running cluster expires. It returns false for clusters which aren't running
+// or weren't created from this machine, as their expiry isn't known.
+func (r Records) ExpiresIn(c ClusterList, now time.Time) (time.Duration, bool) {
+	record, ok := r.Lookup(c)
+	if !ok {
+		return 0, false
+	}
+	if status := Status(c); status != StatusRunning && status != StatusInProgress {
+		return 0, false
+	}
+	expiresAt, ok := record.ExpiresAt(c)
+	if !ok {
+		return 0, false
+	}
+	return expiresAt.Sub(now), true
+}
+
+// RecordExtend remembers that the expiry of a cluster was pushed out by hours.
+func RecordExtend(alias string, hours int) error {
+	records, err := LoadRecords()
+	if err != nil {
+		return err
+	}
+	record, ok := records[alias]
+	if !ok {
+		// The expiry of clusters created elsewhere isn't known, so there's nothing to extend locally.
+		return nil
+	}
+	record.ExtendedHours += hours
+	records[alias] = record
+	return records.Save()
+}
+
+// AdoptIntoFleet marks the clusters with the given aliases as managed by fleet, so 'cluster apply --prune' may remove them.
+func AdoptIntoFleet(aliases []string, fleet string) error {
+	records, err := LoadRecords()
+	if err != nil {
+		return err
+	}
+	for _, alias := range aliases {
+		record, ok := records[alias]
+		if !ok {
+			record = Record{Alias: alias}
+		}
+		record.Fleet = fleet
+		records[alias] = record
+	}
+	return records.Save()
+}
+
+// Forget drops the records of deleted clusters.
+func Forget(aliases ...string) error {
+	records, err := LoadRecords()
+	if err != nil {
+		return err
+	}
+	for _, alias := range aliases {
+		delete(records, alias)
+	}
+	return records.Save()
+}
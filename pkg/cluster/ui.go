@@ -0,0 +1,180 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// UIEndpoint is where the service fitness UI of a cluster is served on its public IP.
+type UIEndpoint struct {
+	Scheme string
+	Port   int
+	Path   string
+}
+
+// DefaultUIEndpoint returns the UI endpoint set by the ui_scheme, ui_port and ui_path settings of the config, which
+// default to http, the NodePort 30070 and /app.
+func DefaultUIEndpoint() UIEndpoint {
+	endpoint := UIEndpoint{Scheme: "http", Port: 30070, Path: "/app"}
+	if scheme := viper.GetString("ui_scheme"); scheme != "" {
+		endpoint.Scheme = scheme
+	}
+	if port := viper.GetInt("ui_port"); port > 0 {
+		endpoint.Port = port
+	}
+	if path := viper.GetString("ui_path"); path != "" {
+		endpoint.Path = path
+	}
+	return endpoint
+}
+
+// URL returns the address of the endpoint on host.
+func (e UIEndpoint) URL(host string) string {
+	path := e.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return fmt.Sprintf("%s://%s%s", e.Scheme, net.JoinHostPort(host, strconv.Itoa(e.Port)), path)
+}
+
+// UIURL returns the address of the service fitness UI of a cluster.
+func UIURL(c ClusterList) string {
+	return DefaultUIEndpoint().URL(c.PublicIP)
+}
+
+// UIService is the Kubernetes service behind the UI NodePort.
+type UIService struct {
+	Namespace string
+	Name      string
+	Port      int
+}
+
+/*
+FindUIService looks up the service exposing nodePort with kubectl, using the kubeconfig at kubeConfigPath.
+// The ui_service setting, as namespace/name:port, names the service instead.
+*/
+func FindUIService(kubeConfigPath string, nodePort int) (UIService, error) {
+	if configured := viper.GetString("ui_service"); configured != "" {
+		return parseUIService(configured)
+	}
+
+	kubectl := exec.Command("kubectl", "get", "services", "--all-namespaces", "-o", "json")
+	kubectl.Env = append(os.Environ(), "KUBECONFIG="+kubeConfigPath)
+	var stderr bytes.Buffer
+	kubectl.Stderr = &stderr
+	out, err := kubectl.Output()
+	if err != nil {
+		return UIService{}, fmt.Errorf("unable to list the services of the cluster: %s %s", err.Error(), strings.TrimSpace(stderr.String()))
+	}
+	var services struct {
+		Items []struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+			Spec struct {
+				Ports []struct {
+					Port     int `json:"port"`
+					NodePort int `json:"nodePort"`
+				} `json:"ports"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &services); err != nil {
+		return UIService{}, err
+	}
+	for _, service := range services.Items {
+		for _, port := range service.Spec.Ports {
+			if port.NodePort == nodePort {
+				return UIService{Namespace: service.Metadata.Namespace, Name: service.Metadata.Name, Port: port.Port}, nil
+			}
+		}
+	}
+	return UIService{}, fmt.Errorf("no service exposes the NodePort %d, set ui_service to namespace/name:port in the config", nodePort)
+}
+
+func parseUIService(value string) (UIService, error) {
+	invalid := fmt.Errorf("invalid ui_service %q, expected namespace/name:port", value)
+	namespace, rest, ok := strings.Cut(value, "/")
+	if !ok {
+		return UIService{}, invalid
+	}
+	name, port, ok := strings.Cut(rest, ":")
+	if !ok || namespace == "" || name == "" {
+		return UIService{}, invalid
+	}
+	number, err := strconv.Atoi(port)
+	if err != nil {
+		return UIService{}, invalid
+	}
+	return UIService{Namespace: namespace, Name: name, Port: number}, nil
+}
+
+// Forward is a running kubectl port-forward.
+type Forward struct {
+	LocalPort int
+	kubectl   *exec.Cmd
+	exited    chan error
+}
+
+// Wait waits for the port-forward to end.
+func (f *Forward) Wait() error {
+	return <-f.exited
+}
+
+// Stop ends the port-forward.
+func (f *Forward) Stop() {
+	f.kubectl.Process.Kill()
+}
+
+/*
+PortForward starts kubectl port-forward from localPort to the service and waits until the local port accepts
+// connections. A localPort of 0 picks a free port. The port-forward runs until it is stopped.
+*/
+func PortForward(kubeConfigPath string, service UIService, localPort int, timeout time.Duration) (*Forward, error) {
+	if localPort == 0 {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, err
+		}
+		localPort = listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+	}
+
+	kubectl := exec.Command("kubectl", "port-forward", "--namespace", service.Namespace, "service/"+service.Name, fmt.Sprintf("%d:%d", localPort, service.Port))
+	kubectl.Env = append(os.Environ(), "KUBECONFIG="+kubeConfigPath)
+	var stderr bytes.Buffer
+	kubectl.Stderr = &stderr
+	if err := kubectl.Start(); err != nil {
+		return nil, err
+	}
+	forward := &Forward{LocalPort: localPort, kubectl: kubectl, exited: make(chan error, 1)}
+	go func() { forward.exited <- kubectl.Wait() }()
+
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort))
+	deadline := time.Now().Add(timeout)
+	for {
+		if conn, err := net.DialTimeout("tcp", address, time.Second); err == nil {
+			conn.Close()
+			return forward, nil
+		}
+		select {
+		case err := <-forward.exited:
+			return nil, fmt.Errorf("kubectl port-forward exited: %v %s", err, strings.TrimSpace(stderr.String()))
+		case <-time.After(200 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			forward.Stop()
+			return nil, fmt.Errorf("kubectl port-forward didn't start listening on %s in %s", address, timeout)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+)
+
+// PhasePending is reported by Wait until the requested cluster shows up in the cluster list.
+const PhasePending = "pending"
+
+// ErrWaitTimeout is returned by Wait when the cluster isn't ready in time.
+var ErrWaitTimeout = errors.New("timed out waiting for the cluster to become ready")
+
+// FailedError is returned by Wait when the cluster reports a failure.
+type FailedError struct {
+	Cluster ClusterList
+}
+
+func (e *FailedError) Error() string {
+	return fmt.Sprintf("cluster %s failed: %s", e.Cluster.CustomerToken, e.Cluster.FailureMsg)
+}
+
+// FetchClusterList is like GetClusterList but returns errors instead of exiting, so it can be polled.
+func FetchClusterList(authToken string) (ClusterListResponse, error) {
+	var clusters ClusterListResponse
+	reqBuff, err := json.Marshal(ClusterListObj{RoostAuthToken: authToken})
+	if err != nil {
+		return clusters, err
+	}
+	status, resp, err := utils.HTTPClientRequest(http.MethodPost, "/api/application/getAppUserClusters", "", bytes.NewReader(reqBuff))
+	if err != nil {
+		return clusters, err
+	}
+	if status != http.StatusCreated {
+		var apiresp ClusterApiResponse
+		json.Unmarshal(resp, &apiresp)
+		return clusters, fmt.Errorf("unable to fetch the cluster list: %d %s", status, apiresp.ClusterRespMessage)
+	}
+	if err = json.Unmarshal(resp, &clusters); err != nil {
+		return clusters, err
+	}
+	return clusters, nil
+}
+
+// FindByAlias returns the cluster requested with the given alias.
+func FindByAlias(clusters []ClusterList, alias string) (ClusterList, bool) {
+	for _, clusterData := range clusters {
+		if clusterData.CustomerToken == alias || clusterData.Alias == alias {
+			return clusterData, true
+		}
+	}
+	return ClusterList{}, false
+}
+
+// Phase describes the state of a cluster for progress output, e.g. "in-progress (Creating nodes)".
+func Phase(c ClusterList) string {
+	message := c.StatusMsg
+	if c.FailureMsg != "" {
+		message = c.FailureMsg
+	}
+	if message == "" {
+		return Status(c)
+	}
+	return fmt.Sprintf("%s (%s)", Status(c), message)
+}
+
+/*
+Wait polls the cluster list every interval until the cluster with the given alias is running, reports a failure,
+or timeout passes. onPhase is called whenever the phase of the cluster changes.
+// Errors fetching the list are retried until the timeout, as the API may briefly be unavailable while the cluster is launched.
+*/
+func Wait(authToken, alias string, timeout, interval time.Duration, onPhase func(phase string)) (ClusterList, error) {
+	deadline := time.Now().Add(timeout)
+	phase := ""
+	for {
+		clusters, err := FetchClusterList(authToken)
+		if err == nil {
+			clusterData, found := FindByAlias(clusters.Clusters, alias)
+			current := PhasePending
+			if found {
+				current = Phase(clusterData)
+			}
+			if current != phase && onPhase != nil {
+				onPhase(current)
+			}
+			phase = current
+			switch {
+			case found && Status(clusterData) == StatusRunning:
+				return clusterData, nil
+			case found && Status(clusterData) == StatusFailed:
+				return clusterData, &FailedError{Cluster: clusterData}
+			}
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			if err != nil {
+				return ClusterList{}, fmt.Errorf("%w, last error: %s", ErrWaitTimeout, err.Error())
+			}
+			return ClusterList{}, ErrWaitTimeout
+		}
+		time.Sleep(interval)
+	}
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/viper"
 )
@@ -13,8 +15,8 @@ type Server struct {
 }
 
 type UserConfigInfo struct{
-	EntServer string `json:"roost_ent_server"`
-	AuthToken string `json:"roost_auth_token"`
+	EntServer string `json:"roost_ent_server" prompt:"Roost Ent Server" help:"Host name of your Roost enterprise server" validate:"required"`
+	AuthToken string `json:"roost_auth_token" prompt:"Roost Auth Token" secret:"true" validate:"required"`
 	//JwtToken string `json:"roost_jwt_token"`
 }
 
@@ -71,3 +73,15 @@ func LoadServerFromViper() error {
 
 	return nil
 }
+
+// Dir returns the directory holding the config file, where roost also keeps its local state.
+func Dir() (string, error) {
+	if used := viper.ConfigFileUsed(); used != "" {
+		return filepath.Dir(used), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".roost"), nil
+}
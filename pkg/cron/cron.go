@@ -0,0 +1,193 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Schedules name their time zone, which has to resolve on machines without a zoneinfo database too.
+	_ "time/tzdata"
+)
+
+// macros are the shorthands accepted in place of the five fields.
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@weekdays": "0 0 * * 1-5",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// field describes one of the five fields of an expression.
+type field struct {
+	name     string
+	min, max int
+	// names are the names of the values from min, such as jan for 1.
+	names []string
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	// 7 is Sunday too, as in most crons.
+	{name: "day of week", min: 0, max: 7, names: dayNames},
+}
+
+/*
+Schedule is a parsed cron expression: minute, hour, day of month, month and day of week, each a list of values, ranges
+such as 1-5, or * for any, optionally with a step such as 8-18/2. Months and days of week may be named, e.g. mon-fri.
+// As in classic cron, a time matches when the day of month or the day of week matches if both are restricted.
+*/
+type Schedule struct {
+	expr                                   string
+	minutes, hours, days, months, weekdays uint64
+	anyDay, anyWeekday                     bool
+}
+
+// Parse parses a cron expression of five fields, or one of the macros such as @daily or @weekdays.
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	spec := expr
+	if strings.HasPrefix(spec, "@") {
+		var ok bool
+		if spec, ok = macros[strings.ToLower(spec)]; !ok {
+			return nil, fmt.Errorf("unknown cron macro %q", expr)
+		}
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid cron expression %q, expected 5 fields: minute hour day-of-month month day-of-week", expr)
+	}
+	s := &Schedule{expr: expr}
+	sets := []*uint64{&s.minutes, &s.hours, &s.days, &s.months, &s.weekdays}
+	for i, part := range parts {
+		set, err := fields[i].parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %s", expr, err.Error())
+		}
+		*sets[i] = set
+	}
+	if s.weekdays&(1<<7) != 0 {
+		s.weekdays |= 1
+	}
+	s.anyDay = parts[2] == "*" || strings.HasPrefix(parts[2], "*/")
+	s.anyWeekday = parts[4] == "*" || strings.HasPrefix(parts[4], "*/")
+	return s, nil
+}
+
+// parse returns the set of values of a field as a bit mask.
+func (f field) parse(part string) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q in the %s", stepPart, f.name)
+			}
+		}
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = f.value(from); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = f.value(to); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// 5/15 means from 5 to the end every 15.
+				high = f.max
+			}
+			if high < low {
+				return 0, fmt.Errorf("invalid range %q in the %s", rangePart, f.name)
+			}
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// value parses a number or name of the field.
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q, expected %d to %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Matches reports whether the schedule fires at the minute of t, in the location of t.
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.minutes&(1<<t.Minute()) != 0 && s.hours&(1<<t.Hour()) != 0 && s.months&(1<<int(t.Month())) != 0 && s.dayMatches(t)
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	day := s.days&(1<<t.Day()) != 0
+	weekday := s.weekdays&(1<<int(t.Weekday())) != 0
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+/*
+Next returns the first time after the given one the schedule fires, in loc. It returns the zero time when the schedule
+never fires within five years, such as on February 30th.
+// Times skipped by a daylight saving change never fire, and those repeated by one fire once.
+*/
+func (s *Schedule) Next(after time.Time, loc *time.Location) time.Time {
+	t := after.In(loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		var next time.Time
+		switch {
+		case s.months&(1<<int(t.Month())) == 0:
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hours&(1<<t.Hour()) == 0:
+			// Moving on in absolute time reaches both occurrences of an hour repeated by a daylight saving change,
+			// time.Date may pick the second one.
+			next = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case s.minutes&(1<<t.Minute()) == 0:
+			next = t.Add(time.Minute)
+		case t.Add(-time.Hour).Hour() == t.Hour():
+			// The wall clock repeats this hour after a daylight saving change, and it already fired the first time.
+			next = t.Add(time.Minute)
+		default:
+			return t
+		}
+		if !next.After(t) {
+			// A daylight saving change moved the wall clock back, so move on in absolute time instead.
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+	return time.Time{}
+}
@@ -0,0 +1,183 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@fortnightly",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"* * * foo *",
+		"1-x * * * *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	utc := func(value string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04 Mon", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	tests := []struct {
+		expr string
+		time string
+		want bool
+	}{
+		{"* * * * *", "2023-05-10 12:34 Wed", true},
+		{"30 9 * * *", "2023-05-10 09:30 Wed", true},
+		{"30 9 * * *", "2023-05-10 09:31 Wed", false},
+		{"*/15 * * * *", "2023-05-10 09:45 Wed", true},
+		{"*/15 * * * *", "2023-05-10 09:50 Wed", false},
+		{"5/15 * * * *", "2023-05-10 09:50 Wed", true},
+		{"5/15 * * * *", "2023-05-10 09:00 Wed", false},
+		{"0 8-18/2 * * *", "2023-05-10 14:00 Wed", true},
+		{"0 8-18/2 * * *", "2023-05-10 15:00 Wed", false},
+		{"0 8-18/2 * * *", "2023-05-10 20:00 Wed", false},
+		{"0 9,17 * * *", "2023-05-10 17:00 Wed", true},
+		{"0 9 * * mon-fri", "2023-05-10 09:00 Wed", true},
+		{"0 9 * * MON-FRI", "2023-05-13 09:00 Sat", false},
+		{"0 9 * * 7", "2023-05-14 09:00 Sun", true},
+		{"0 9 * * 0", "2023-05-14 09:00 Sun", true},
+		{"0 9 * jan,may *", "2023-05-10 09:00 Wed", true},
+		{"0 9 * jun-dec *", "2023-05-10 09:00 Wed", false},
+		{"@daily", "2023-05-10 00:00 Wed", true},
+		{"@hourly", "2023-05-10 13:00 Wed", true},
+		{"@weekdays", "2023-05-14 00:00 Sun", false},
+		{"@monthly", "2023-05-01 00:00 Mon", true},
+		// Both days restricted: the day of month or the day of week has to match.
+		{"0 0 13 * fri", "2023-05-13 00:00 Sat", true},
+		{"0 0 13 * fri", "2023-05-12 00:00 Fri", true},
+		{"0 0 13 * fri", "2023-05-11 00:00 Thu", false},
+		// Only one restricted: it has to match.
+		{"0 0 13 * *", "2023-05-12 00:00 Fri", false},
+		{"0 0 * * fri", "2023-05-13 00:00 Sat", false},
+		{"0 0 */2 * fri", "2023-05-13 00:00 Sat", false},
+		{"0 0 */2 * fri", "2023-05-12 00:00 Fri", false},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+		}
+		if got := s.Matches(utc(tt.time)); got != tt.want {
+			t.Errorf("%q matches %s = %v, want %v", tt.expr, tt.time, got, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(value string) time.Time {
+		tm, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	tests := []struct {
+		name  string
+		expr  string
+		after string
+		want  []string
+	}{
+		{
+			name:  "every 15 minutes",
+			expr:  "*/15 * * * *",
+			after: "2023-05-10T09:07:30Z",
+			want:  []string{"2023-05-10T11:15:00+02:00", "2023-05-10T11:30:00+02:00"},
+		},
+		{
+			name:  "weekdays skip the weekend",
+			expr:  "0 19 * * mon-fri",
+			after: "2023-05-12T18:00:00Z",
+			want:  []string{"2023-05-15T19:00:00+02:00"},
+		},
+		{
+			name:  "end of month",
+			expr:  "0 0 31 * *",
+			after: "2023-04-01T00:00:00Z",
+			want:  []string{"2023-05-31T00:00:00+02:00", "2023-07-31T00:00:00+02:00"},
+		},
+		{
+			name:  "leap day",
+			expr:  "0 12 29 feb *",
+			after: "2023-01-01T00:00:00Z",
+			want:  []string{"2024-02-29T12:00:00+01:00"},
+		},
+		{
+			// 02:30 doesn't exist on March 26th 2023, the clocks jump from 02:00 to 03:00.
+			name:  "spring forward skips the missing time",
+			expr:  "30 2 * * *",
+			after: "2023-03-25T12:00:00Z",
+			want:  []string{"2023-03-27T02:30:00+02:00"},
+		},
+		{
+			name:  "spring forward keeps the hours around the gap",
+			expr:  "0 * * * *",
+			after: "2023-03-26T00:30:00+01:00",
+			want:  []string{"2023-03-26T01:00:00+01:00", "2023-03-26T03:00:00+02:00", "2023-03-26T04:00:00+02:00"},
+		},
+		{
+			// 02:30 happens twice on October 29th 2023, the clocks go back from 03:00 to 02:00.
+			name:  "fall back fires once in the repeated hour",
+			expr:  "30 2 * * *",
+			after: "2023-10-28T12:00:00Z",
+			want:  []string{"2023-10-29T02:30:00+02:00", "2023-10-30T02:30:00+01:00"},
+		},
+		{
+			name:  "fall back hourly",
+			expr:  "0 * * * *",
+			after: "2023-10-29T00:30:00+02:00",
+			want:  []string{"2023-10-29T01:00:00+02:00", "2023-10-29T02:00:00+02:00", "2023-10-29T03:00:00+01:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+			}
+			next := at(tt.after)
+			for _, want := range tt.want {
+				next = s.Next(next, berlin)
+				if !next.Equal(at(want)) {
+					t.Fatalf("Next() = %s, want %s", next.Format(time.RFC3339), want)
+				}
+				if next.Location() != berlin {
+					t.Errorf("Next() is in %s, want Europe/Berlin", next.Location())
+				}
+			}
+		})
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	s, err := Parse("0 0 30 feb *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := s.Next(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.UTC); !next.IsZero() {
+		t.Errorf("Next() = %s, want the zero time", next)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/ZB-io/internal/roostcli/pkg/resolve"
 	"github.com/ZB-io/internal/roostcli/pkg/spinner"
 	"github.com/ZB-io/internal/roostcli/pkg/utils"
 	"github.com/spf13/cobra"
@@ -92,22 +93,31 @@ type workFlowID struct {
 }
 
 func GetWorkFlowID(req GetWorkFlowIDReq) string {
+	return GetWorkFlowIDs(req)[0]
+}
+
+// GetWorkFlowIDs returns the IDs of all the workflows associated with an application.
+func GetWorkFlowIDs(req GetWorkFlowIDReq) []string {
 	apiEndPoint := "/api/application/client/git/workflow/get"
 	reqBuff, err := json.Marshal(req)
 	cobra.CheckErr(err)
 
 	body := bytes.NewReader(reqBuff)
-    authkey:="Bearer "+viper.Get("roost_jwt_token").(string)
+	authkey := "Bearer " + viper.Get("roost_jwt_token").(string)
 	status, respbody, err := utils.HTTPClientRequest(http.MethodPost, apiEndPoint, authkey, body)
 	cobra.CheckErr(err)
-    if status != 201 {
-        fmt.Println(status)
-    }
+	if status != 201 {
+		fmt.Println(status)
+	}
 	var resp workflowIDResp
 	err = json.Unmarshal(respbody, &resp)
 	cobra.CheckErr(err)
 
-	return resp.Data[0].WorkFlowID
+	var ids []string
+	for _, workflow := range resp.Data {
+		ids = append(ids, workflow.WorkFlowID)
+	}
+	return ids
 }
 
 func GetEaasList (isSetAll bool) (list EaaslistResp) {
@@ -212,4 +222,33 @@ func GetEaasListEnv(take int) ListEnvResp{
 	cobra.CheckErr(err)
 	spinner.Stop(true)
 	return getEaasList
-}
\ No newline at end of file
+}
+// ResolveApps finds the applications referred to by query, which may be an application ID, name,
+// unique prefix of either, or a glob such as 'web-*' when allowGlob is set.
+func ResolveApps(query string, apps []Eaaslistdata, allowGlob bool) ([]Eaaslistdata, error) {
+	candidates := make([]resolve.Candidate, len(apps))
+	for i, app := range apps {
+		candidates[i] = resolve.Candidate{ID: app.ID, Names: []string{app.Appname}}
+	}
+
+	var matches []int
+	if allowGlob {
+		var err error
+		matches, err = resolve.Resolve("application", query, candidates)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		match, err := resolve.ResolveOne("application", query, candidates)
+		if err != nil {
+			return nil, err
+		}
+		matches = []int{match}
+	}
+
+	var resolved []Eaaslistdata
+	for _, i := range matches {
+		resolved = append(resolved, apps[i])
+	}
+	return resolved, nil
+}
@@ -0,0 +1,364 @@
+package kubeconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a kubeconfig file. Fields roost doesn't use are kept in Extra so they survive a rewrite.
+type Config struct {
+	APIVersion     string         `yaml:"apiVersion"`
+	Kind           string         `yaml:"kind"`
+	Clusters       []NamedCluster `yaml:"clusters"`
+	Users          []NamedUser    `yaml:"users"`
+	Contexts       []NamedContext `yaml:"contexts"`
+	CurrentContext string         `yaml:"current-context"`
+	Extra          map[string]any `yaml:",inline"`
+	// dir is the directory of the file the kubeconfig was loaded from, which relative paths in it are relative to.
+	dir string
+}
+
+type NamedCluster struct {
+	Name    string         `yaml:"name"`
+	Cluster Cluster        `yaml:"cluster"`
+	Extra   map[string]any `yaml:",inline"`
+}
+
+type Cluster struct {
+	Server                   string         `yaml:"server"`
+	CertificateAuthority     string         `yaml:"certificate-authority,omitempty"`
+	CertificateAuthorityData string         `yaml:"certificate-authority-data,omitempty"`
+	InsecureSkipTLSVerify    bool           `yaml:"insecure-skip-tls-verify,omitempty"`
+	Extra                    map[string]any `yaml:",inline"`
+}
+
+type NamedUser struct {
+	Name  string         `yaml:"name"`
+	User  User           `yaml:"user"`
+	Extra map[string]any `yaml:",inline"`
+}
+
+type User struct {
+	ClientCertificate     string         `yaml:"client-certificate,omitempty"`
+	ClientCertificateData string         `yaml:"client-certificate-data,omitempty"`
+	ClientKey             string         `yaml:"client-key,omitempty"`
+	ClientKeyData         string         `yaml:"client-key-data,omitempty"`
+	Token                 string         `yaml:"token,omitempty"`
+	Extra                 map[string]any `yaml:",inline"`
+}
+
+type NamedContext struct {
+	Name    string         `yaml:"name"`
+	Context Context        `yaml:"context"`
+	Extra   map[string]any `yaml:",inline"`
+}
+
+type Context struct {
+	Cluster   string         `yaml:"cluster"`
+	User      string         `yaml:"user"`
+	Namespace string         `yaml:"namespace,omitempty"`
+	Extra     map[string]any `yaml:",inline"`
+}
+
+// Parse parses a kubeconfig.
+func Parse(data []byte) (*Config, error) {
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %s", err.Error())
+	}
+	return config, nil
+}
+
+// Load reads the kubeconfig at path. A missing file is an empty kubeconfig.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{APIVersion: "v1", Kind: "Config"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	config, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	config.dir = filepath.Dir(path)
+	return config, nil
+}
+
+// Save writes the kubeconfig to path, see WriteFile.
+func (c *Config) Save(path string) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	return WriteFile(path, buf.Bytes())
+}
+
+// WriteFile writes a kubeconfig readable only by its owner. It writes to a temporary file which is then renamed,
+// so readers never see a half written kubeconfig.
+func WriteFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// DefaultPath returns the kubeconfig kubectl uses: the first file in $KUBECONFIG, or ~/.kube/config.
+func DefaultPath() (string, error) {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return filepath.SplitList(env)[0], nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".kube", "config"), nil
+}
+
+// Context returns the named context, or the current one when name is empty.
+func (c *Config) Context(name string) (NamedContext, bool) {
+	if name == "" {
+		name = c.CurrentContext
+	}
+	for _, context := range c.Contexts {
+		if context.Name == name {
+			return context, true
+		}
+	}
+	if name == "" && len(c.Contexts) == 1 {
+		return c.Contexts[0], true
+	}
+	return NamedContext{}, false
+}
+
+// Cluster returns the named cluster.
+func (c *Config) Cluster(name string) (NamedCluster, bool) {
+	for _, cluster := range c.Clusters {
+		if cluster.Name == name {
+			return cluster, true
+		}
+	}
+	return NamedCluster{}, false
+}
+
+// User returns the named user.
+func (c *Config) User(name string) (NamedUser, bool) {
+	for _, user := range c.Users {
+		if user.Name == name {
+			return user, true
+		}
+	}
+	return NamedUser{}, false
+}
+
+/*
+Merge copies the current context of src, with its cluster and user, into c under name.
+// Entries of c which already have that name are replaced, so merging the same cluster again updates it in place.
+*/
+func (c *Config) Merge(src *Config, name string) error {
+	context, ok := src.Context("")
+	if !ok {
+		return fmt.Errorf("the kubeconfig has no current context")
+	}
+	cluster, ok := src.Cluster(context.Context.Cluster)
+	if !ok {
+		return fmt.Errorf("the kubeconfig has no cluster %q", context.Context.Cluster)
+	}
+	user, ok := src.User(context.Context.User)
+	if !ok {
+		return fmt.Errorf("the kubeconfig has no user %q", context.Context.User)
+	}
+
+	current := c.CurrentContext
+	c.Remove(name)
+	c.CurrentContext = current
+	cluster.Name, user.Name, context.Name = name, name, name
+	context.Context.Cluster, context.Context.User = name, name
+	c.Clusters = append(c.Clusters, cluster)
+	c.Users = append(c.Users, user)
+	c.Contexts = append(c.Contexts, context)
+	if c.APIVersion == "" {
+		c.APIVersion = "v1"
+	}
+	if c.Kind == "" {
+		c.Kind = "Config"
+	}
+	return nil
+}
+
+// Remove deletes the context, cluster and user with the given name and reports whether anything was removed.
+// If it was the current context, there is no current context afterwards.
+func (c *Config) Remove(name string) bool {
+	removed := false
+	clusters := c.Clusters[:0]
+	for _, cluster := range c.Clusters {
+		if cluster.Name == name {
+			removed = true
+			continue
+		}
+		clusters = append(clusters, cluster)
+	}
+	c.Clusters = clusters
+
+	users := c.Users[:0]
+	for _, user := range c.Users {
+		if user.Name == name {
+			removed = true
+			continue
+		}
+		users = append(users, user)
+	}
+	c.Users = users
+
+	contexts := c.Contexts[:0]
+	for _, context := range c.Contexts {
+		if context.Name == name {
+			removed = true
+			continue
+		}
+		contexts = append(contexts, context)
+	}
+	c.Contexts = contexts
+
+	if c.CurrentContext == name {
+		c.CurrentContext = ""
+	}
+	return removed
+}
+
+// ContextsWithPrefix returns the names of the contexts starting with prefix.
+func (c *Config) ContextsWithPrefix(prefix string) []string {
+	var names []string
+	for _, context := range c.Contexts {
+		if strings.HasPrefix(context.Name, prefix) {
+			names = append(names, context.Name)
+		}
+	}
+	return names
+}
+
+// MergeInto merges the kubeconfig in data into the kubeconfig at path under name, optionally making it the current context.
+func MergeInto(path, name string, data []byte, switchContext bool) error {
+	src, err := ParseValid(data)
+	if err != nil {
+		return err
+	}
+	dst, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := dst.Merge(src, name); err != nil {
+		return err
+	}
+	if switchContext {
+		dst.CurrentContext = name
+	}
+	return dst.Save(path)
+}
+
+// RemoveFrom removes the entries merged under name from the kubeconfig at path, and reports whether there were any.
+func RemoveFrom(path, name string) (bool, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	config, err := Load(path)
+	if err != nil {
+		return false, err
+	}
+	if !config.Remove(name) {
+		return false, nil
+	}
+	return true, config.Save(path)
+}
+
+// Server returns the API server of the named context, or of the current one when name is empty.
+func (c *Config) Server(name string) string {
+	context, ok := c.Context(name)
+	if !ok {
+		return ""
+	}
+	cluster, _ := c.Cluster(context.Context.Cluster)
+	return cluster.Cluster.Server
+}
+
+// UseContext makes name the current context of the kubeconfig at path.
+func UseContext(path, name string) error {
+	config, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := config.Context(name); !ok {
+		return fmt.Errorf("%s has no context %s", path, name)
+	}
+	config.CurrentContext = name
+	return config.Save(path)
+}
+
+// Reachable checks that the API server accepts connections within timeout. It doesn't authenticate.
+func Reachable(server string, timeout time.Duration) error {
+	u, err := url.Parse(server)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid server address %q", server)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	conn, err := net.DialTimeout("tcp", host, timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// SetNamespace sets the namespace of the current context.
+func (c *Config) SetNamespace(namespace string) error {
+	current, ok := c.Context("")
+	if !ok {
+		return fmt.Errorf("the kubeconfig has no current context")
+	}
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == current.Name {
+			c.Contexts[i].Context.Namespace = namespace
+		}
+	}
+	return nil
+}
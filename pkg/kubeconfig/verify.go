@@ -0,0 +1,246 @@
+package kubeconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+ParseValid parses a downloaded kubeconfig and checks it can be used, see Validate, so a broken download is reported
+instead of being written over a working kubeconfig.
+*/
+func ParseValid(data []byte) (*Config, error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, fmt.Errorf("invalid kubeconfig: it is empty")
+	}
+	config, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+/*
+Validate checks that the current context of the kubeconfig, or its only context, names a cluster with a server address
+and a user with credentials, and that their certificates decode.
+*/
+func (c *Config) Validate() error {
+	context, ok := c.Context("")
+	if !ok {
+		return fmt.Errorf("invalid kubeconfig: it has no current context")
+	}
+	cluster, ok := c.Cluster(context.Context.Cluster)
+	if !ok {
+		return fmt.Errorf("invalid kubeconfig: context %s names the missing cluster %q", context.Name, context.Context.Cluster)
+	}
+	if u, err := url.Parse(cluster.Cluster.Server); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("invalid kubeconfig: cluster %s has the invalid server address %q", cluster.Name, cluster.Cluster.Server)
+	}
+	if _, _, err := c.certificateAuthorities(cluster.Cluster); err != nil {
+		return fmt.Errorf("invalid kubeconfig: the certificate authority of cluster %s: %s", cluster.Name, err.Error())
+	}
+	user, ok := c.User(context.Context.User)
+	if !ok {
+		return fmt.Errorf("invalid kubeconfig: context %s names the missing user %q", context.Name, context.Context.User)
+	}
+	credentials := user.User
+	_, exec := credentials.Extra["exec"]
+	_, authProvider := credentials.Extra["auth-provider"]
+	_, tokenFile := credentials.Extra["tokenFile"]
+	switch {
+	case hasClientCertificate(credentials):
+		if _, err := c.clientCertificate(credentials); err != nil {
+			return fmt.Errorf("invalid kubeconfig: the client certificate of user %s: %s", user.Name, err.Error())
+		}
+	case credentials.Token == "" && !tokenFile && !exec && !authProvider:
+		return fmt.Errorf("invalid kubeconfig: user %s has no credentials", user.Name)
+	}
+	return nil
+}
+
+// readFile reads a file named by the kubeconfig, relative to the kubeconfig when it was loaded from one.
+func (c *Config) readFile(path string) ([]byte, error) {
+	if !filepath.IsAbs(path) && c.dir != "" {
+		path = filepath.Join(c.dir, path)
+	}
+	return os.ReadFile(path)
+}
+
+/*
+certificateAuthorities returns the PEM bundle of the certificate authorities of a cluster, from
+certificate-authority-data or else the certificate-authority file, and the certificates in it. Bundles often hold
+intermediates besides the root, so every certificate counts. Without either, both are nil.
+*/
+func (c *Config) certificateAuthorities(cluster Cluster) ([]byte, []*x509.Certificate, error) {
+	var bundle []byte
+	var err error
+	switch {
+	case cluster.CertificateAuthorityData != "":
+		if bundle, err = base64.StdEncoding.DecodeString(cluster.CertificateAuthorityData); err != nil {
+			return nil, nil, fmt.Errorf("not base64: %s", err.Error())
+		}
+	case cluster.CertificateAuthority != "":
+		if bundle, err = c.readFile(cluster.CertificateAuthority); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, nil
+	}
+
+	var certs []*x509.Certificate
+	for rest := bundle; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, nil, fmt.Errorf("no PEM certificate found")
+	}
+	return bundle, certs, nil
+}
+
+func hasClientCertificate(user User) bool {
+	return user.ClientCertificateData != "" || user.ClientCertificate != "" || user.ClientKeyData != "" || user.ClientKey != ""
+}
+
+// clientCertificate returns the TLS client certificate of a user, from the -data fields or else the files they name.
+func (c *Config) clientCertificate(user User) (tls.Certificate, error) {
+	cert, err := c.credentialData(user.ClientCertificateData, user.ClientCertificate)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	key, err := c.credentialData(user.ClientKeyData, user.ClientKey)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("the key: %s", err.Error())
+	}
+	return tls.X509KeyPair(cert, key)
+}
+
+func (c *Config) credentialData(data, path string) ([]byte, error) {
+	if data == "" && path != "" {
+		return c.readFile(path)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("not base64: %s", err.Error())
+	}
+	return decoded, nil
+}
+
+// certificateName names a certificate by its common name, or its whole subject without one.
+func certificateName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	return cert.Subject.String()
+}
+
+// CertificateExpiry is when a certificate involved in reaching the API server expires.
+type CertificateExpiry struct {
+	Name     string    `json:"name"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// Health is what Verify found out about the API server of a kubeconfig.
+type Health struct {
+	Server  string `json:"server"`
+	Version string `json:"version"`
+	// Certificates are those of the client, the certificate authorities and the server, when there are.
+	Certificates []CertificateExpiry `json:"certificates"`
+}
+
+/*
+Verify probes /version of the API server of the named context, or of the current one when name is empty, with the
+certificates and credentials of the kubeconfig, and reports when its certificates expire.
+// /version is usually readable without credentials, so a healthy server doesn't prove they are accepted, but
+// rejected ones are reported.
+*/
+func Verify(c *Config, name string, timeout time.Duration) (Health, error) {
+	var health Health
+	context, ok := c.Context(name)
+	if !ok {
+		return health, fmt.Errorf("the kubeconfig has no context %s", name)
+	}
+	cluster, _ := c.Cluster(context.Context.Cluster)
+	user, _ := c.User(context.Context.User)
+	health.Server = cluster.Cluster.Server
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cluster.Cluster.InsecureSkipTLSVerify}
+	if hasClientCertificate(user.User) {
+		cert, err := c.clientCertificate(user.User)
+		if err != nil {
+			return health, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+			health.Certificates = append(health.Certificates, CertificateExpiry{"client certificate", leaf.NotAfter})
+		}
+	}
+	bundle, cas, err := c.certificateAuthorities(cluster.Cluster)
+	if err != nil {
+		return health, err
+	}
+	if bundle != nil {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		tlsConfig.RootCAs.AppendCertsFromPEM(bundle)
+	}
+	for _, ca := range cas {
+		health.Certificates = append(health.Certificates, CertificateExpiry{fmt.Sprintf("certificate authority %q", certificateName(ca)), ca.NotAfter})
+	}
+
+	request, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(cluster.Cluster.Server, "/")+"/version", nil)
+	if err != nil {
+		return health, err
+	}
+	if user.User.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+user.User.Token)
+	}
+	client := &http.Client{Timeout: timeout, Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment}}
+	resp, err := client.Do(request)
+	if err != nil {
+		return health, fmt.Errorf("the API server %s is not usable: %s", health.Server, err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		health.Certificates = append(health.Certificates, CertificateExpiry{"server certificate", resp.TLS.PeerCertificates[0].NotAfter})
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return health, fmt.Errorf("the API server %s rejected the credentials of the kubeconfig: %s", health.Server, resp.Status)
+	default:
+		return health, fmt.Errorf("the API server %s answered %s", health.Server, resp.Status)
+	}
+	var version struct {
+		GitVersion string `json:"gitVersion"`
+	}
+	if err := json.Unmarshal(body, &version); err != nil || version.GitVersion == "" {
+		return health, fmt.Errorf("%s/version doesn't look like a Kubernetes API server", health.Server)
+	}
+	health.Version = version.GitVersion
+	return health, nil
+}
@@ -0,0 +1,161 @@
+package kubeconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unrelatedCA returns a PEM certificate authority which signed nothing the tests use.
+func unrelatedCA(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "unrelated"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// apiServer serves /version over TLS and returns it with its certificate in PEM.
+func apiServer(t *testing.T) (*httptest.Server, []byte) {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"gitVersion":"v1.27.3"}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
+
+func kubeconfigFor(server, caField string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: k
+  cluster:
+    server: %s
+    %s
+users:
+- name: u
+  user: {token: t}
+contexts:
+- name: c
+  context: {cluster: k, user: u}
+current-context: c
+`, server, caField)
+}
+
+func TestVerifyCABundle(t *testing.T) {
+	server, serverCA := apiServer(t)
+	// The certificate authority of the server comes second, as in bundles with an old and a new one.
+	bundle := append(unrelatedCA(t), serverCA...)
+	config, err := ParseValid([]byte(kubeconfigFor(server.URL, "certificate-authority-data: "+base64.StdEncoding.EncodeToString(bundle))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	health, err := Verify(config, "", 5*time.Second)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if health.Version != "v1.27.3" {
+		t.Errorf("version = %q, want v1.27.3", health.Version)
+	}
+	var names []string
+	for _, cert := range health.Certificates {
+		names = append(names, cert.Name)
+	}
+	if len(names) != 3 || !strings.Contains(names[0], "unrelated") || names[2] != "server certificate" {
+		t.Errorf("certificates = %v, want both certificate authorities and the server certificate", names)
+	}
+}
+
+func TestVerifyCAFile(t *testing.T) {
+	server, serverCA := apiServer(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ca.crt"), serverCA, 0600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config")
+	// A relative path is relative to the kubeconfig.
+	if err := os.WriteFile(path, []byte(kubeconfigFor(server.URL, "certificate-authority: ca.crt")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if _, err := Verify(config, "", 5*time.Second); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
+
+func TestVerifyUntrustedServer(t *testing.T) {
+	server, _ := apiServer(t)
+	config, err := ParseValid([]byte(kubeconfigFor(server.URL, "certificate-authority-data: "+base64.StdEncoding.EncodeToString(unrelatedCA(t)))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(config, "", 5*time.Second); err == nil {
+		t.Fatal("Verify() trusted a server signed by another certificate authority")
+	}
+}
+
+func TestParseValid(t *testing.T) {
+	notPEM := base64.StdEncoding.EncodeToString([]byte("not a certificate"))
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"empty", "  \n", "it is empty"},
+		{"not yaml", "clusters: [", "invalid kubeconfig"},
+		{"no context", "apiVersion: v1\nkind: Config\n", "no current context"},
+		{"invalid server", kubeconfigFor("9.9.9.9:6443", ""), "invalid server address"},
+		{"no certificate in the bundle", kubeconfigFor("https://9.9.9.9", "certificate-authority-data: "+notPEM), "no PEM certificate"},
+		{"not base64", kubeconfigFor("https://9.9.9.9", "certificate-authority-data: '%%'"), "not base64"},
+		{"missing file", kubeconfigFor("https://9.9.9.9", "certificate-authority: /nonexistent/ca.crt"), "no such file"},
+		{"no credentials", strings.Replace(kubeconfigFor("https://9.9.9.9", ""), "{token: t}", "{}", 1), "has no credentials"},
+		{"valid", kubeconfigFor("https://9.9.9.9:6443", ""), ""},
+	}
+	for _, tt := range tests {
+		_, err := ParseValid([]byte(tt.data))
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: ParseValid() error = %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: ParseValid() error = %v, want one containing %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package pricing
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ZB-io/internal/roostcli/pkg/config"
+	"github.com/spf13/viper"
+)
+
+// hoursPerMonth is how AWS turns monthly prices into hourly ones.
+const hoursPerMonth = 730
+
+//go:embed pricing.json
+var bundled []byte
+
+/*
+Table holds the on-demand prices cluster costs are estimated with. Instance prices are hourly prices in a base region,
+scaled by the multiplier of the region the cluster runs in, as regional price differences are about the same for every
+instance type.
+// The bundled table is a snapshot, so prices can be updated with a local file, see Load.
+*/
+type Table struct {
+	Currency string `json:"currency"`
+	// Updated is the date the prices were taken.
+	Updated             string             `json:"updated"`
+	ControlPlaneNodes   int                `json:"control_plane_nodes"`
+	DefaultInstanceType string             `json:"default_instance_type"`
+	DefaultRegion       string             `json:"default_region"`
+	Instances           map[string]float64 `json:"instances"`
+	Regions             map[string]float64 `json:"regions"`
+	DiskGBMonth         float64            `json:"disk_gb_month"`
+}
+
+// Bundled returns the pricing table shipped with roost.
+func Bundled() (Table, error) {
+	var table Table
+	err := json.Unmarshal(bundled, &table)
+	return table, err
+}
+
+// OverridePath returns the file overriding the bundled prices: the pricing_file setting, or pricing.json next to the config.
+func OverridePath() (string, error) {
+	if path := viper.GetString("pricing_file"); path != "" {
+		return path, nil
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pricing.json"), nil
+}
+
+/*
+Load returns the bundled table updated with the override file, if there is one, and the path of the file it used.
+Only what the file sets is overridden, so it may just add an instance type or change the price of one.
+*/
+func Load() (Table, string, error) {
+	table, err := Bundled()
+	if err != nil {
+		return table, "", err
+	}
+	path, err := OverridePath()
+	if err != nil {
+		return table, "", err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && viper.GetString("pricing_file") == "" {
+		return table, "", nil
+	}
+	if err != nil {
+		return table, "", err
+	}
+	var override Table
+	if err := json.Unmarshal(data, &override); err != nil {
+		return table, "", fmt.Errorf("invalid pricing file %s: %s", path, err.Error())
+	}
+	table.merge(override)
+	return table, path, nil
+}
+
+func (t *Table) merge(o Table) {
+	if o.Currency != "" {
+		t.Currency = o.Currency
+	}
+	if o.Updated != "" {
+		t.Updated = o.Updated
+	}
+	if o.ControlPlaneNodes > 0 {
+		t.ControlPlaneNodes = o.ControlPlaneNodes
+	}
+	if o.DefaultInstanceType != "" {
+		t.DefaultInstanceType = o.DefaultInstanceType
+	}
+	if o.DefaultRegion != "" {
+		t.DefaultRegion = o.DefaultRegion
+	}
+	for instanceType, price := range o.Instances {
+		t.Instances[instanceType] = price
+	}
+	for region, multiplier := range o.Regions {
+		t.Regions[region] = multiplier
+	}
+	if o.DiskGBMonth > 0 {
+		t.DiskGBMonth = o.DiskGBMonth
+	}
+}
+
+// NodeHourly returns the hourly price of one node.
+func (t Table) NodeHourly(instanceType, region string) (float64, error) {
+	price, ok := t.Instances[instanceType]
+	if !ok {
+		return 0, fmt.Errorf("no price for instance type %q, known types are %s", instanceType, strings.Join(sortedKeys(t.Instances), ", "))
+	}
+	multiplier, err := t.regionMultiplier(region)
+	return price * multiplier, err
+}
+
+// DiskHourly returns the hourly price of gb gigabytes of disk.
+func (t Table) DiskHourly(gb int, region string) (float64, error) {
+	multiplier, err := t.regionMultiplier(region)
+	return float64(gb) * t.DiskGBMonth / hoursPerMonth * multiplier, err
+}
+
+func (t Table) regionMultiplier(region string) (float64, error) {
+	multiplier, ok := t.Regions[region]
+	if !ok {
+		return 0, fmt.Errorf("no prices for region %q, known regions are %s", region, strings.Join(sortedKeys(t.Regions), ", "))
+	}
+	return multiplier, nil
+}
+
+// Format formats an amount in the currency of the table, e.g. "$1.25" or "1.25 EUR". Amounts below 1 keep a third
+// decimal, as hourly prices of small clusters are a few cents.
+func (t Table) Format(amount float64) string {
+	value := fmt.Sprintf("%.2f", amount)
+	if amount > 0 && amount < 1 {
+		value = fmt.Sprintf("%.3f", amount)
+	}
+	if t.Currency == "" || t.Currency == "USD" {
+		return "$" + value
+	}
+	return value + " " + t.Currency
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
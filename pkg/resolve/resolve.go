@@ -0,0 +1,187 @@
+package resolve
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Candidate is something the user can refer to by its ID or by one of its names.
+type Candidate struct {
+	ID    string
+	Names []string
+}
+
+func (c Candidate) keys() []string {
+	return append([]string{c.ID}, c.Names...)
+}
+
+func (c Candidate) String() string {
+	if len(c.Names) > 0 && c.Names[0] != "" {
+		return fmt.Sprintf("%s (%s)", c.Names[0], c.ID)
+	}
+	return c.ID
+}
+
+// NotFoundError is returned when nothing matches the query.
+type NotFoundError struct {
+	Kind        string
+	Query       string
+	Suggestions []string
+}
+
+func (e *NotFoundError) Error() string {
+	msg := fmt.Sprintf("%s %q not found", e.Kind, e.Query)
+	if len(e.Suggestions) > 0 {
+		msg += ", did you mean " + strings.Join(e.Suggestions, " or ") + "?"
+	}
+	return msg
+}
+
+// AmbiguousError is returned when the query matches more than one candidate but only one was expected.
+type AmbiguousError struct {
+	Kind    string
+	Query   string
+	Matches []string
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("%s %q is ambiguous, did you mean %s?", e.Kind, e.Query, strings.Join(e.Matches, ", "))
+}
+
+// IsGlob reports whether query contains glob wildcards.
+func IsGlob(query string) bool {
+	return strings.ContainsAny(query, "*?[")
+}
+
+/*
+Resolve finds the candidates a query refers to and returns their indexes.
+// kind: what is being looked up, e.g. "cluster". Only used in error messages.
+// An exact ID or name wins over a prefix, a prefix has to be unique, and a glob such as 'ci-*' may match many candidates.
+*/
+func Resolve(kind, query string, candidates []Candidate) ([]int, error) {
+	if IsGlob(query) {
+		var matches []int
+		for i, c := range candidates {
+			for _, key := range c.keys() {
+				if ok, err := path.Match(query, key); err != nil {
+					return nil, fmt.Errorf("invalid pattern %q: %s", query, err.Error())
+				} else if ok {
+					matches = append(matches, i)
+					break
+				}
+			}
+		}
+		if len(matches) == 0 {
+			return nil, &NotFoundError{Kind: kind, Query: query}
+		}
+		return matches, nil
+	}
+
+	for _, match := range []func(key string) bool{
+		func(key string) bool { return key == query },
+		func(key string) bool { return strings.HasPrefix(key, query) },
+	} {
+		var matches []int
+		for i, c := range candidates {
+			for _, key := range c.keys() {
+				if key != "" && match(key) {
+					matches = append(matches, i)
+					break
+				}
+			}
+		}
+		if len(matches) == 1 {
+			return matches, nil
+		}
+		if len(matches) > 1 {
+			var names []string
+			for _, i := range matches {
+				names = append(names, candidates[i].String())
+			}
+			return nil, &AmbiguousError{Kind: kind, Query: query, Matches: names}
+		}
+	}
+
+	var names []string
+	for _, c := range candidates {
+		names = append(names, c.Names...)
+	}
+	return nil, &NotFoundError{Kind: kind, Query: query, Suggestions: Suggest(query, names, 3)}
+}
+
+// ResolveOne is like Resolve but fails unless exactly one candidate matches.
+func ResolveOne(kind, query string, candidates []Candidate) (int, error) {
+	matches, err := Resolve(kind, query, candidates)
+	if err != nil {
+		return -1, err
+	}
+	if len(matches) > 1 {
+		var names []string
+		for _, i := range matches {
+			names = append(names, candidates[i].String())
+		}
+		return -1, &AmbiguousError{Kind: kind, Query: query, Matches: names}
+	}
+	return matches[0], nil
+}
+
+// Suggest returns up to n of the given names which are closest to query, for "did you mean" hints.
+func Suggest(query string, names []string, n int) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+	limit := len(query)/3 + 1
+	seen := map[string]bool{}
+	var close []scored
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if d := Distance(strings.ToLower(query), strings.ToLower(name)); d <= limit {
+			close = append(close, scored{name, d})
+		}
+	}
+	sort.SliceStable(close, func(i, j int) bool { return close[i].distance < close[j].distance })
+
+	var suggestions []string
+	for i := 0; i < len(close) && i < n; i++ {
+		suggestions = append(suggestions, close[i].name)
+	}
+	return suggestions
+}
+
+// Distance returns the Levenshtein edit distance between a and b.
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,99 @@
+package resolve
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var candidates = []Candidate{
+	{ID: "1", Names: []string{"web"}},
+	{ID: "2", Names: []string{"web-staging"}},
+	{ID: "3", Names: []string{"ci-build"}},
+	{ID: "4", Names: []string{"ci-test"}},
+	{ID: "12", Names: []string{"api"}},
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+		err   any
+	}{
+		{name: "exact name wins over prefix", query: "web", want: []int{0}},
+		{name: "exact id wins over prefix", query: "1", want: []int{0}},
+		{name: "unique name prefix", query: "web-s", want: []int{1}},
+		{name: "unique id prefix", query: "12", want: []int{4}},
+		{name: "ambiguous prefix", query: "ci-", err: &AmbiguousError{}},
+		{name: "glob matches many", query: "ci-*", want: []int{2, 3}},
+		{name: "glob matches ids", query: "1?", want: []int{4}},
+		{name: "glob matching nothing", query: "db-*", err: &NotFoundError{}},
+		{name: "not found", query: "db", err: &NotFoundError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve("cluster", tt.query, candidates)
+			if tt.err != nil {
+				if err == nil || reflect.TypeOf(err) != reflect.TypeOf(tt.err) {
+					t.Fatalf("Resolve(%q) error = %v, want a %T", tt.query, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q) error = %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveInvalidPattern(t *testing.T) {
+	if _, err := Resolve("cluster", "ci-[", candidates); err == nil {
+		t.Fatal("Resolve(\"ci-[\") succeeded, want an invalid pattern error")
+	}
+}
+
+func TestResolveOne(t *testing.T) {
+	if got, err := ResolveOne("cluster", "api", candidates); err != nil || got != 4 {
+		t.Errorf("ResolveOne(\"api\") = %d, %v, want 4", got, err)
+	}
+	var ambiguous *AmbiguousError
+	if _, err := ResolveOne("cluster", "ci-*", candidates); !errors.As(err, &ambiguous) {
+		t.Errorf("ResolveOne(\"ci-*\") error = %v, want an AmbiguousError", err)
+	}
+}
+
+func TestNotFoundSuggestions(t *testing.T) {
+	_, err := Resolve("cluster", "wbe", candidates)
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("Resolve(\"wbe\") error = %v, want a NotFoundError", err)
+	}
+	if want := []string{"web"}; !reflect.DeepEqual(notFound.Suggestions, want) {
+		t.Errorf("suggestions = %v, want %v", notFound.Suggestions, want)
+	}
+	if want := `cluster "wbe" not found, did you mean web?`; err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"web", "web", 0},
+		{"web", "wbe", 2},
+		{"kitten", "sitting", 3},
+		{"", "api", 3},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
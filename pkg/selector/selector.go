@@ -0,0 +1,147 @@
+package selector
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ZB-io/internal/roostcli/pkg/utils"
+)
+
+// operators are checked in order, so the two character ones have to come first.
+var operators = []string{"!=", "==", ">=", "<=", "=", ">", "<"}
+
+// Requirement is a single condition such as status=running or age>4h.
+type Requirement struct {
+	Key      string
+	Operator string
+	Value    string
+}
+
+// Selector is a list of requirements which all have to match.
+type Selector []Requirement
+
+/*
+Parse parses a comma separated list of requirements.
+// Supported operators are =, ==, != which accept globs such as '*@ourco.com', and >, <, >=, <= which compare
+// numbers or durations such as 4h or 2d.
+*/
+func Parse(expr string) (Selector, error) {
+	var s Selector
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		r, err := parseRequirement(part)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, r)
+	}
+	return s, nil
+}
+
+func parseRequirement(part string) (Requirement, error) {
+	for _, op := range operators {
+		if i := strings.Index(part, op); i > 0 {
+			r := Requirement{Key: strings.TrimSpace(part[:i]), Operator: op, Value: strings.TrimSpace(part[i+len(op):])}
+			if r.Operator == "==" {
+				r.Operator = "="
+			}
+			return r, nil
+		}
+	}
+	return Requirement{}, fmt.Errorf("invalid requirement %q, expected key=value, key!=value or key>value", part)
+}
+
+// Matches reports whether fields satisfy every requirement. Requirements on keys missing from fields are an error,
+// so typos don't silently select nothing.
+func (s Selector) Matches(fields map[string]string) (bool, error) {
+	for _, r := range s {
+		value, ok := fields[r.Key]
+		if !ok {
+			return false, fmt.Errorf("unknown key %q, valid keys are %s", r.Key, strings.Join(keys(fields), ", "))
+		}
+		matched, err := r.matches(value)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (r Requirement) matches(value string) (bool, error) {
+	switch r.Operator {
+	case "=", "!=":
+		equal := strings.EqualFold(value, r.Value)
+		if !equal && strings.ContainsAny(r.Value, "*?[") {
+			var err error
+			equal, err = path.Match(strings.ToLower(r.Value), strings.ToLower(value))
+			if err != nil {
+				return false, fmt.Errorf("invalid pattern %q: %s", r.Value, err.Error())
+			}
+		}
+		return equal == (r.Operator == "="), nil
+	}
+
+	// Unknown values, such as the age of a cluster without a creation time, never compare.
+	if value == "" {
+		return false, nil
+	}
+	cmp, err := compare(value, r.Value)
+	if err != nil {
+		return false, fmt.Errorf("%s%s%s: %s", r.Key, r.Operator, r.Value, err.Error())
+	}
+	switch r.Operator {
+	case ">":
+		return cmp > 0, nil
+	case "<":
+		return cmp < 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	default:
+		return cmp <= 0, nil
+	}
+}
+
+// compare compares two numbers or two durations.
+func compare(a, b string) (int, error) {
+	if fa, err := strconv.ParseFloat(a, 64); err == nil {
+		fb, err := strconv.ParseFloat(b, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", b)
+		}
+		return sign(fa - fb), nil
+	}
+	da, err := utils.ParseDuration(a)
+	if err != nil {
+		return 0, fmt.Errorf("%q can't be compared", a)
+	}
+	db, err := utils.ParseDuration(b)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration", b)
+	}
+	return sign(float64(da - db)), nil
+}
+
+func sign(f float64) int {
+	switch {
+	case f > 0:
+		return 1
+	case f < 0:
+		return -1
+	}
+	return 0
+}
+
+func keys(fields map[string]string) []string {
+	var k []string
+	for key := range fields {
+		k = append(k, key)
+	}
+	sort.Strings(k)
+	return k
+}
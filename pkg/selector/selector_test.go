@@ -0,0 +1,107 @@
+package selector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		want Selector
+	}{
+		{"", nil},
+		{"status=running", Selector{{"status", "=", "running"}}},
+		{"status==running", Selector{{"status", "=", "running"}}},
+		{"status!=running", Selector{{"status", "!=", "running"}}},
+		{"age>=2d", Selector{{"age", ">=", "2d"}}},
+		{"age<=4h", Selector{{"age", "<=", "4h"}}},
+		{"nodes>1", Selector{{"nodes", ">", "1"}}},
+		{"nodes<3", Selector{{"nodes", "<", "3"}}},
+		{" status = running , email=*@ourco.com ,", Selector{{"status", "=", "running"}, {"email", "=", "*@ourco.com"}}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"running", "=running", "status=running,age"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	fields := map[string]string{
+		"status": "Running",
+		"email":  "ci@ourco.com",
+		"nodes":  "3",
+		"age":    "5h0m0s",
+		"team":   "",
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"status=running", true},
+		{"status!=running", false},
+		{"status!=stopped", true},
+		{"email=*@ourco.com", true},
+		{"email!=*@ourco.com", false},
+		{"email=ci@*", true},
+		{"nodes>1", true},
+		{"nodes>3", false},
+		{"nodes>=3", true},
+		{"nodes<=2.5", false},
+		{"age>4h", true},
+		{"age>1d", false},
+		{"age<1w", true},
+		{"age>=300m", true},
+		{"team=", true},
+		{"team!=web", true},
+		// Unknown values never compare.
+		{"team>1", false},
+		{"status=running,nodes>1,age>4h", true},
+		{"status=running,nodes>5", false},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+		}
+		got, err := s.Matches(fields)
+		if err != nil {
+			t.Fatalf("%q: Matches() error = %v", tt.expr, err)
+		}
+		if got != tt.want {
+			t.Errorf("%q: Matches() = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesErrors(t *testing.T) {
+	fields := map[string]string{"status": "running", "nodes": "3", "age": "5h"}
+	for _, expr := range []string{
+		"region=us-east-1", // unknown key
+		"nodes>many",       // not a number
+		"age>old",          // not a duration
+		"status>1",         // can't be compared
+		"status=[",         // invalid pattern
+	} {
+		s, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", expr, err)
+		}
+		if _, err := s.Matches(fields); err == nil {
+			t.Errorf("%q: Matches() succeeded, want an error", expr)
+		}
+	}
+}
@@ -7,39 +7,42 @@ import (
 
 type Spinner struct {
     stopChan chan struct{}
+    doneChan chan struct{}
+    started  bool
 }
 
 func NewSpinner() *Spinner {
     return &Spinner{
         stopChan: make(chan struct{}),
+        doneChan: make(chan struct{}),
     }
 }
 
 func (s *Spinner) Start(Message string) {
+    s.started = true
+    frames := []string{"\r🔆 %s.   ", "\r🔅 %s..  ", "\r🔆 %s... ", "\r🔅 %s...."}
     go func() {
-        for {
+        defer close(s.doneChan)
+        for i := 0; ; i = (i + 1) % len(frames) {
+            fmt.Printf(frames[i], Message)
             select {
             case <-s.stopChan:
                 return
-            default:
-                fmt.Printf("\r🔆 %s.   ", Message)
-                time.Sleep(100 * time.Millisecond)
-                fmt.Printf("\r🔅 %s..  ", Message)
-                time.Sleep(100 * time.Millisecond)
-                fmt.Printf("\r🔆 %s... ", Message)
-                time.Sleep(100 * time.Millisecond)
-                fmt.Printf("\r🔅 %s....", Message)
-                time.Sleep(100 * time.Millisecond)
+            case <-time.After(100 * time.Millisecond):
             }
         }
     }()
 }
 
+// Stop stops the animation and prints the result. No frame is printed after the result.
 func (s *Spinner) Stop(result bool) {
+    close(s.stopChan)
+    if s.started {
+        <-s.doneChan
+    }
     if result {
         fmt.Print("\033[2K\r✔️ Success\n")
     } else {
         fmt.Print("\033[2K\r❌ Failure\n")
     }
-    close(s.stopChan)
 }
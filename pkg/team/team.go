@@ -1,5 +1,13 @@
 package team
 
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ZB-io/internal/roostcli/pkg/resolve"
+)
+
 type TeamListResponse struct {
 	Teamlist []TeamList `json:"teams"`
 	Count    int        `json:"count"`
@@ -23,11 +31,11 @@ type TeamList struct {
 }
 
 type CreateTeam struct {
-	Description  string   `json:"description"`
-	FirstMembers []string `json:"firstMembers"`
-	Name         string   `json:"name"`
-	Org          string   `json:"org"`
-	Visibility   string   `json:"visibility"`
+	Description  string   `json:"description" prompt:"Description"`
+	FirstMembers []string `json:"firstMembers" prompt:"Members" help:"Comma separated usernames to invite"`
+	Name         string   `json:"name" prompt:"Team Name" validate:"required,max=64"`
+	Org          string   `json:"org" prompt:"Organisation"`
+	Visibility   string   `json:"visibility" prompt:"Visibility" options:"private|public"`
 }
 
 type DeleteTeam struct {
@@ -63,11 +71,11 @@ type Credentialfile struct {
 }
 
 type AwsCredentials struct {
-	CredentialInputType string `json:"credentials_input_type"`
-	AccesskeyID         string `json:"access_key_id"`
+	CredentialInputType string `json:"credentials_input_type" prompt:"-"`
+	AccesskeyID         string `json:"access_key_id" prompt:"AWS Access Key ID" secret:"true"`
 	Credentialfile      `json:"credentials_file"`
-	SecretAccesskey     string `json:"secret_access_key"`
-	SessionToken        string `json:"session_token"`
+	SecretAccesskey     string `json:"secret_access_key" prompt:"AWS Secret Access Key" secret:"true"`
+	SessionToken        string `json:"session_token" prompt:"AWS Session Token" secret:"true"`
 }
 
 type UpdateClusterInfo struct {
@@ -87,8 +95,38 @@ type TeamKubeConfigObj struct {
 }
 
 type Teamconfig struct {
-	Helmrepopwd        string `json:"helm_repo_pwd"`
-	Helmrepousername   string `json:"helm_repo_username"`
-	Restrictuseraccess bool   `json:"restrictuseraccess"`
-	TeamClusterId      int    `json:"team_cluster_id"`
+	Helmrepopwd        string `json:"helm_repo_pwd" prompt:"Helm Repo Password" secret:"true"`
+	Helmrepousername   string `json:"helm_repo_username" prompt:"Helm Repo Username"`
+	Restrictuseraccess bool   `json:"restrictuseraccess" prompt:"Restrict User Access" help:"Only team members get access to the cluster"`
+	TeamClusterId      int    `json:"team_cluster_id" prompt:"-"`
+}
+
+// Resolve finds the team referred to by query, which may be a team ID, a team name or a unique prefix of either.
+func Resolve(query string, teams []TeamList) (TeamList, error) {
+	candidates := make([]resolve.Candidate, len(teams))
+	for i, teamData := range teams {
+		candidates[i] = resolve.Candidate{ID: teamData.TeamId, Names: []string{teamData.Name}}
+	}
+	match, err := resolve.ResolveOne("team", query, candidates)
+	if err != nil {
+		return TeamList{}, err
+	}
+	return teams[match], nil
+}
+
+// ContextPrefix starts the names of the contexts team cluster kubeconfigs are merged under.
+const ContextPrefix = "roost-team-"
+
+// ContextName is the name of the context, cluster and user a team cluster's kubeconfig is merged under.
+func ContextName(teamName string) string {
+	return ContextPrefix + strings.Join(strings.Fields(teamName), "-")
+}
+
+// KubeconfigPath returns where 'team get-kubeconfig' stores the kubeconfig of a team's cluster.
+func KubeconfigPath(teamName string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".kube", "roostteamconfig", teamName), nil
 }
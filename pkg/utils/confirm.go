@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// redactedKeys are payload keys whose values are hidden when printing a dry run.
+var redactedKeys = []string{"roost_auth_token", "app_user_id", "secret_access_key", "session_token", "helm_repo_pwd"}
+
+// stdin is shared by all prompts, as a reader of its own could buffer the answers piped for the next prompts.
+var stdin = bufio.NewReader(os.Stdin)
+
+func readAnswer() string {
+	answer, err := stdin.ReadString('\n')
+	if err != nil && answer == "" {
+		return ""
+	}
+	return strings.TrimSpace(answer)
+}
+
+// Confirm prints the prompt and reads a yes/no answer from stdin. Anything other than y or yes,
+// including a closed stdin, counts as no.
+func Confirm(prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+	answer := strings.ToLower(readAnswer())
+	return answer == "y" || answer == "yes"
+}
+
+// ConfirmByName asks the user to type name and reports whether it was typed exactly.
+func ConfirmByName(name string) bool {
+	fmt.Printf("Type %q to confirm: ", name)
+	return readAnswer() == name
+}
+
+/*
+ConfirmDestructive shows what is about to be destroyed and asks the user to go ahead.
+// yes: skips the question, as with the --yes flag.
+// name: when the require_typed_confirmation setting or the --confirm-name flag is on, the user has to type it instead
+// of answering y.
+*/
+func ConfirmDestructive(summary []string, name string, yes bool) bool {
+	fmt.Println("The following will be permanently removed:")
+	for _, line := range summary {
+		fmt.Println("  -", line)
+	}
+	if yes {
+		return true
+	}
+	if name != "" && viper.GetBool("require_typed_confirmation") {
+		return ConfirmByName(name)
+	}
+	return Confirm("Do you want to continue?")
+}
+
+// PrintDryRun prints the API call which would be made, with credentials in the payload redacted.
+func PrintDryRun(operation, command string, payload any) {
+	fmt.Printf("[dry-run] %s %s%s\n", operation, ServerURL(), command)
+	if payload == nil {
+		return
+	}
+
+	reqBuff, err := json.Marshal(payload)
+	if err != nil {
+		fmt.Println("[dry-run] unable to encode payload:", err)
+		return
+	}
+	var fields any
+	if err := json.Unmarshal(reqBuff, &fields); err != nil {
+		fmt.Println(string(reqBuff))
+		return
+	}
+	redact(fields)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", " ")
+	encoder.Encode(fields)
+}
+
+func redact(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			for _, secret := range redactedKeys {
+				if key == secret && value != "" {
+					v[key] = "<redacted>"
+				}
+			}
+			redact(v[key])
+		}
+	case []any:
+		for _, value := range v {
+			redact(value)
+		}
+	}
+}
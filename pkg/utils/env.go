@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+/*
+ExpandEnv replaces $VAR and ${VAR} in text with the values of environment variables.
+// ${VAR:-default} falls back to default when VAR is unset or empty and $$ is a literal $.
+// Variables which are unset and have no default are an error, so a missing variable never silently becomes "".
+*/
+func ExpandEnv(text string) (string, error) {
+	missing := map[string]bool{}
+	expanded := os.Expand(text, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		name, fallback, hasDefault := strings.Cut(name, ":-")
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+		if hasDefault {
+			return fallback
+		}
+		if _, ok := os.LookupEnv(name); !ok {
+			missing[name] = true
+		}
+		return ""
+	})
+	if len(missing) > 0 {
+		var names []string
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("environment variables not set: %s", strings.Join(names, ", "))
+	}
+	return expanded, nil
+}
@@ -0,0 +1,607 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ZB-io/internal/roostcli/pkg/resolve"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+/*
+AcceptFromPrompt is an utility function which accepts default request data. Prompts user to get it modified if needed.
+// to: must be pointer to struct with exported fields.
+// Supported types are int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, string, bool and []string
+//
+// The form is driven by struct tags:
+//
+//	prompt:"Cluster Alias"        label shown instead of the field name, "-" skips the field
+//	help:"Shown under the field"  help text for the focused field
+//	validate:"required,min=1,max=10,regex=^[a-z]+$"
+//	                              min/max bound numbers, or the length of strings. regex must come last.
+//	options:"t3.small|t3.medium"  enum picked with left/right
+//	secret:"true"                 masks the input
+//
+// Structs implementing FieldRules add options and checks which aren't known when the tags are written.
+// Fields of other kinds, such as nested structs, are skipped.
+*/
+func AcceptFromPrompt(to any) error {
+	var err error
+	v := reflect.Indirect(reflect.ValueOf(to))
+	if !v.CanSet() {
+		return fmt.Errorf("can't update field from prompt. Pass reference of struct in function call to allow updation")
+	}
+
+	if v.Kind() == reflect.Struct {
+		err = PromptTextInput(&v)
+	}
+	return err
+
+}
+
+var (
+	focusedStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("50"))
+	blurredStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("250"))
+	cursorStyle         = focusedStyle.Copy()
+	noStyle             = lipgloss.NewStyle()
+	helpStylePrompt     = blurredStyle.Copy()
+	cursorModeHelpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("50"))
+	errorStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	fieldHelpStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240")).PaddingLeft(4)
+
+	focusedButton = focusedStyle.Copy().Render("[ Submit ]")
+	blurredButton = fmt.Sprintf("[ %s ]", blurredStyle.Render("Submit"))
+)
+
+// FieldRules is implemented by structs whose allowed values come from elsewhere than their tags, such as a catalogue.
+// Both methods get the name of the struct field.
+type FieldRules interface {
+	// FieldOptions returns the values a field may take, like the options tag, or nil.
+	FieldOptions(field string) []string
+	// CheckField returns what is wrong with the value of a field, or "".
+	CheckField(field, value string) string
+}
+
+// fieldRules returns the FieldRules of the struct v, or nil.
+func fieldRules(v reflect.Value) FieldRules {
+	if rules, ok := v.Interface().(FieldRules); ok {
+		return rules
+	}
+	if v.CanAddr() {
+		if rules, ok := v.Addr().Interface().(FieldRules); ok {
+			return rules
+		}
+	}
+	return nil
+}
+
+// formField holds the prompt state of one struct field.
+type formField struct {
+	index    int
+	label    string
+	help     string
+	kind     reflect.Kind
+	required bool
+	min      *float64
+	max      *float64
+	pattern  *regexp.Regexp
+	options  []string
+	secret   bool
+	check    func(value string) string
+
+	input      textinput.Model
+	defaultVal string
+	option     int
+	boolValue  bool
+	err        string
+}
+
+// value returns what the field currently holds in the form, falling back to
+// the default when nothing was typed.
+func (f *formField) value() string {
+	switch {
+	case f.kind == reflect.Bool:
+		return strconv.FormatBool(f.boolValue)
+	case f.options != nil:
+		if f.option < 0 {
+			return f.defaultVal
+		}
+		return f.options[f.option]
+	case f.input.Value() != "":
+		return f.input.Value()
+	}
+	return f.defaultVal
+}
+
+// validate checks the current value against the field's rules and records the
+// problem, if any, so it can be shown inline.
+func (f *formField) validate() bool {
+	f.err = ""
+	value := strings.TrimSpace(f.value())
+
+	if value == "" {
+		if f.required {
+			f.err = "required"
+		}
+		return f.err == ""
+	}
+
+	if f.options != nil && f.option < 0 {
+		f.err = fmt.Sprintf("%q is not one of the allowed values, use ←/→ to pick one", value)
+		return false
+	}
+
+	var size float64
+	var unit string
+	switch f.kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		d, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			f.err = "must be a number"
+			return false
+		}
+		size = d
+	case reflect.String, reflect.Slice:
+		size = float64(len([]rune(value)))
+		unit = " characters"
+	}
+	if f.min != nil && size < *f.min {
+		f.err = fmt.Sprintf("must be at least %v%s", *f.min, unit)
+		return false
+	}
+	if f.max != nil && size > *f.max {
+		f.err = fmt.Sprintf("must be at most %v%s", *f.max, unit)
+		return false
+	}
+	if f.pattern != nil && !f.pattern.MatchString(value) {
+		f.err = fmt.Sprintf("must match %s", f.pattern.String())
+		return false
+	}
+	if f.check != nil {
+		f.err = f.check(value)
+	}
+	return f.err == ""
+}
+
+// newFormField builds the prompt state for a struct field from its tags and the
+// rules of its struct, which may be nil. It returns false for fields which
+// cannot or should not be prompted for.
+func newFormField(field reflect.StructField, value reflect.Value, index int, rules FieldRules) (formField, bool, error) {
+	f := formField{index: index, label: field.Name, kind: value.Kind(), option: -1}
+
+	if !field.IsExported() || field.Tag.Get("prompt") == "-" {
+		return f, false, nil
+	}
+	switch f.kind {
+	case reflect.Struct, reflect.Map, reflect.Pointer, reflect.Interface, reflect.Func, reflect.Chan, reflect.Array:
+		return f, false, nil
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			return f, false, nil
+		}
+	}
+
+	if label := field.Tag.Get("prompt"); label != "" {
+		f.label = label
+	}
+	f.help = field.Tag.Get("help")
+	f.secret = field.Tag.Get("secret") == "true"
+
+	if rules := field.Tag.Get("validate"); rules != "" {
+		for rules != "" {
+			var rule string
+			if strings.HasPrefix(rules, "regex=") {
+				rule, rules = rules, ""
+			} else {
+				rule, rules, _ = strings.Cut(rules, ",")
+			}
+			name, arg, _ := strings.Cut(rule, "=")
+			switch name {
+			case "required":
+				f.required = true
+			case "min", "max":
+				d, err := strconv.ParseFloat(arg, 64)
+				if err != nil {
+					return f, false, fmt.Errorf("invalid %s rule on field %s: %q", name, field.Name, arg)
+				}
+				if name == "min" {
+					f.min = &d
+				} else {
+					f.max = &d
+				}
+			case "regex":
+				re, err := regexp.Compile(arg)
+				if err != nil {
+					return f, false, fmt.Errorf("invalid regex rule on field %s: %q", field.Name, err.Error())
+				}
+				f.pattern = re
+			default:
+				return f, false, fmt.Errorf("unknown validation rule %q on field %s", name, field.Name)
+			}
+		}
+	}
+
+	switch f.kind {
+	case reflect.Bool:
+		f.boolValue = value.Bool()
+	case reflect.Slice:
+		f.defaultVal = strings.Join(value.Interface().([]string), ",")
+	default:
+		f.defaultVal = fmt.Sprint(value.Interface())
+	}
+
+	if rules != nil {
+		name := field.Name
+		f.check = func(value string) string { return rules.CheckField(name, value) }
+		if options := rules.FieldOptions(name); len(options) > 0 && f.kind != reflect.Bool {
+			f.options = options
+		}
+	}
+	if options := field.Tag.Get("options"); options != "" && f.kind != reflect.Bool {
+		f.options = strings.Split(options, "|")
+	}
+	if f.options != nil {
+		for i, option := range f.options {
+			if option == f.defaultVal {
+				f.option = i
+			}
+		}
+		if f.defaultVal == "" {
+			f.option = 0
+		}
+	}
+
+	t := textinput.New()
+	t.CursorStyle = cursorStyle
+	t.Prompt = "> " + f.label + ": "
+	if f.secret {
+		t.EchoMode = textinput.EchoPassword
+		t.EchoCharacter = '•'
+		if f.defaultVal != "" {
+			t.Placeholder = " " + strings.Repeat("•", 8)
+		}
+	} else {
+		t.Placeholder = " " + f.defaultVal
+	}
+	if f.max != nil && (f.kind == reflect.String || f.kind == reflect.Slice) {
+		t.CharLimit = int(*f.max)
+	} else {
+		t.CharLimit = 0
+	}
+	f.input = t
+
+	return f, true, nil
+}
+
+type promptmodel struct {
+	focusIndex int
+	fields     []formField
+	cursorMode textinput.CursorMode
+	quitting   bool
+}
+
+func initialModel(promptfields *reflect.Value) (promptmodel, error) {
+	m := promptmodel{}
+
+	rules := fieldRules(*promptfields)
+	for i := 0; i < promptfields.NumField(); i++ {
+		f, ok, err := newFormField(promptfields.Type().Field(i), promptfields.Field(i), i, rules)
+		if err != nil {
+			return m, err
+		}
+		if ok {
+			m.fields = append(m.fields, f)
+		}
+	}
+	m.setFocus()
+
+	return m, nil
+}
+
+// setFocus moves the focused state to the field at focusIndex.
+func (m *promptmodel) setFocus() tea.Cmd {
+	cmds := make([]tea.Cmd, len(m.fields))
+	for i := range m.fields {
+		if i == m.focusIndex {
+			cmds[i] = m.fields[i].input.Focus()
+			m.fields[i].input.PromptStyle = focusedStyle
+			m.fields[i].input.TextStyle = focusedStyle
+			continue
+		}
+		m.fields[i].input.Blur()
+		m.fields[i].input.PromptStyle = noStyle
+		m.fields[i].input.TextStyle = noStyle
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m promptmodel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m promptmodel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		var focused *formField
+		if m.focusIndex < len(m.fields) {
+			focused = &m.fields[m.focusIndex]
+		}
+
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.quitting = true
+			return m, tea.Quit
+
+		// Change cursor mode
+		case "ctrl+r":
+			m.cursorMode++
+			if m.cursorMode > textinput.CursorHide {
+				m.cursorMode = textinput.CursorBlink
+			}
+			cmds := make([]tea.Cmd, len(m.fields))
+			for i := range m.fields {
+				cmds[i] = m.fields[i].input.SetCursorMode(m.cursorMode)
+			}
+			return m, tea.Batch(cmds...)
+
+		// Cycle enum values and toggle booleans
+		case "left", "right", " ":
+			if focused != nil && focused.kind == reflect.Bool {
+				focused.boolValue = !focused.boolValue
+				focused.validate()
+				return m, nil
+			}
+			if focused != nil && focused.options != nil && msg.String() != " " {
+				if msg.String() == "left" {
+					focused.option--
+				} else {
+					focused.option++
+				}
+				if focused.option < 0 {
+					focused.option = len(focused.options) - 1
+				} else if focused.option >= len(focused.options) {
+					focused.option = 0
+				}
+				focused.validate()
+				return m, nil
+			}
+
+		// Set focus to next input
+		case "tab", "shift+tab", "enter", "up", "down":
+			s := msg.String()
+
+			// Did the user press enter while the submit button was focused?
+			// If so, exit unless a field is invalid, which then gets the focus.
+			if s == "enter" && m.focusIndex == len(m.fields) {
+				for i := range m.fields {
+					if !m.fields[i].validate() {
+						m.focusIndex = i
+						return m, m.setFocus()
+					}
+				}
+				return m, tea.Quit
+			}
+
+			if focused != nil {
+				focused.validate()
+			}
+
+			// Cycle indexes
+			if s == "up" || s == "shift+tab" {
+				m.focusIndex--
+			} else {
+				m.focusIndex++
+			}
+
+			if m.focusIndex > len(m.fields) {
+				m.focusIndex = 0
+			} else if m.focusIndex < 0 {
+				m.focusIndex = len(m.fields)
+			}
+
+			return m, m.setFocus()
+		}
+
+		// Only free text fields take typed input.
+		if focused != nil && (focused.kind == reflect.Bool || focused.options != nil) {
+			return m, nil
+		}
+	}
+
+	// Handle character input and blinking
+	cmd := m.updateInputs(msg)
+
+	return m, cmd
+}
+
+func (m *promptmodel) updateInputs(msg tea.Msg) tea.Cmd {
+	cmds := make([]tea.Cmd, len(m.fields))
+
+	// Only text inputs with Focus() set will respond, so it's safe to simply
+	// update all of them here without any further logic.
+	for i := range m.fields {
+		m.fields[i].input, cmds[i] = m.fields[i].input.Update(msg)
+	}
+
+	return tea.Batch(cmds...)
+}
+
+func (m promptmodel) View() string {
+	var b strings.Builder
+
+	for i := range m.fields {
+		f := &m.fields[i]
+		style := noStyle
+		if i == m.focusIndex {
+			style = focusedStyle
+		}
+
+		switch {
+		case f.kind == reflect.Bool:
+			box := "[ ]"
+			if f.boolValue {
+				box = "[x]"
+			}
+			b.WriteString(style.Render(fmt.Sprintf("> %s: %s", f.label, box)))
+		case f.options != nil:
+			b.WriteString(style.Render(fmt.Sprintf("> %s: ‹ %s ›", f.label, f.value())))
+		default:
+			b.WriteString(f.input.View())
+		}
+		if f.required {
+			b.WriteString(helpStylePrompt.Render(" *"))
+		}
+		if f.err != "" {
+			b.WriteString("\n" + errorStyle.Render("    ✗ "+f.err))
+		} else if i == m.focusIndex && f.help != "" {
+			b.WriteString("\n" + fieldHelpStyle.Render(f.help))
+		}
+		if i < len(m.fields)-1 {
+			b.WriteRune('\n')
+		}
+	}
+
+	button := &blurredButton
+	if m.focusIndex == len(m.fields) {
+		button = &focusedButton
+	}
+	fmt.Fprintf(&b, "\n\n%s\n\n", *button)
+
+	b.WriteString(helpStylePrompt.Render("←/→ pick option • space toggle • * required • cursor mode is "))
+	b.WriteString(cursorModeHelpStyle.Render(m.cursorMode.String()))
+	b.WriteString(helpStylePrompt.Render(" (ctrl+r to change style)"))
+
+	return b.String()
+}
+
+// FieldError is a validation failure of one struct field.
+type FieldError struct {
+	Field   reflect.StructField
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field.Name + ": " + e.Message
+}
+
+// Validate checks the fields of the struct v points to against the same tags and FieldRules AcceptFromPrompt uses, and
+// returns every failure rather than just the first. Values missing from the options suggest the closest ones.
+func Validate(v any) ([]FieldError, error) {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can't validate a %s, pass a struct", value.Kind())
+	}
+	var failures []FieldError
+	rules := fieldRules(value)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		f, ok, err := newFormField(field, value.Field(i), i, rules)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if f.options != nil && f.option < 0 && f.defaultVal != "" {
+			message := fmt.Sprintf("%q must be one of %s", f.defaultVal, strings.Join(f.options, ", "))
+			if suggestions := resolve.Suggest(f.defaultVal, f.options, 1); len(suggestions) > 0 {
+				message = fmt.Sprintf("%q is not allowed, did you mean %s? Use one of %s", f.defaultVal, suggestions[0], strings.Join(f.options, ", "))
+			}
+			failures = append(failures, FieldError{Field: field, Message: message})
+			continue
+		}
+		if !f.validate() {
+			failures = append(failures, FieldError{Field: field, Message: f.err})
+		}
+	}
+	return failures, nil
+}
+
+func PromptTextInput(promptvalue *reflect.Value) error {
+
+	model, err := initialModel(promptvalue)
+	if err != nil {
+		return err
+	}
+	if len(model.fields) == 0 {
+		return nil
+	}
+
+	x, err := tea.NewProgram(model).Run()
+	if err != nil {
+		fmt.Printf("could not start program: %s\n", err)
+		os.Exit(1)
+	}
+
+	m, _ := x.(promptmodel)
+	if m.quitting {
+		return fmt.Errorf(":Prompt Exit")
+	}
+
+	for _, f := range m.fields {
+		field := promptvalue.Field(f.index)
+		if err := validateinput(&field, strings.TrimSpace(f.value())); err != nil {
+			return fmt.Errorf("%s: %s", f.label, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func validateinput(field *reflect.Value, promptValue string) error {
+	fieldKind := field.Kind()
+
+	switch fieldKind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		d, err := strconv.ParseInt(promptValue, 0, 64)
+		if err != nil {
+			return fmt.Errorf("string to int64 conversion error %q", err.Error())
+		}
+		field.SetInt(reflect.ValueOf(d).Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		d, err := strconv.ParseUint(promptValue, 0, 64)
+		if err != nil {
+			return fmt.Errorf("string to uint64 conversion error %q", err.Error())
+		}
+		field.SetUint(reflect.ValueOf(d).Uint())
+
+	case reflect.Float32, reflect.Float64:
+		d, err := strconv.ParseFloat(promptValue, 64)
+		if err != nil {
+			return fmt.Errorf("string to float64 conversion error %q", err.Error())
+		}
+		field.SetFloat(reflect.ValueOf(d).Float())
+
+	case reflect.Bool:
+		d, err := strconv.ParseBool(promptValue)
+		if err != nil {
+			return fmt.Errorf("string to bool conversion error %q", err.Error())
+		}
+		field.SetBool(d)
+
+	case reflect.String:
+		field.SetString(promptValue)
+
+	case reflect.Slice:
+		var values []string
+		for _, v := range strings.Split(promptValue, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+
+	default:
+		return fmt.Errorf("unsupported field type provided %q. supports int, int64, uint64, float64, string, bool, []string", fieldKind)
+	}
+	return nil
+}
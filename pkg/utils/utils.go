@@ -7,14 +7,12 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
-	"reflect"
 	"runtime"
-	"strconv"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/table"
-	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/viper"
@@ -58,9 +56,19 @@ func FileOrFolderExists(filename string) bool {
 	return !os.IsNotExist(err)
 }
 
+// ServerURL returns the base URL of the Roost enterprise server. roost_ent_server is a host name, reached over https,
+// or a URL with its scheme, e.g. http://localhost:8080 for a local stand-in server.
+func ServerURL() string {
+	server, _ := viper.Get("roost_ent_server").(string)
+	if strings.Contains(server, "://") {
+		return strings.TrimSuffix(server, "/")
+	}
+	return "https://" + server
+}
+
 func HTTPClientRequest(operation, command, authKey string, params io.Reader) (int, []byte, error) {
 	client := &http.Client{}
-	url := "https://" + viper.Get("roost_ent_server").(string) + command
+	url := ServerURL() + command
 	req, err := http.NewRequest(operation, url, params)
 	if err != nil {
 		return http.StatusBadRequest, nil, errors.New("Failed to create HTTP request." + err.Error())
@@ -85,26 +93,6 @@ func HTTPClientRequest(operation, command, authKey string, params io.Reader) (in
 	return resp.StatusCode, body, ioErr
 }
 
-/*
-AcceptFromPrompt is an utility function which accepts default request data. Prompts user to get it modified if needed.
-// to: must be pointer to struct with exported fields.
-// Supported types are int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, ,float32, float64, string
-*/
-func AcceptFromPrompt(to any) error {
-	var err error
-	v := reflect.Indirect(reflect.ValueOf(to))
-	t := reflect.TypeOf(v)
-	if !v.CanSet() {
-		return fmt.Errorf("can't update field from prompt. Pass reference of struct in function call to allow updation")
-	}
-
-	if t.Kind() == reflect.Struct {
-		err = PromptTextInput(&v)
-	}
-	return err
-
-}
-
 const listHeight = 14
 
 var (
@@ -114,13 +102,18 @@ var (
 	paginationStyle   = list.DefaultStyles().PaginationStyle.PaddingLeft(4)
 	helpStyle         = list.DefaultStyles().HelpStyle.PaddingLeft(4).PaddingBottom(1)
 	quitTextStyle     = lipgloss.NewStyle().Margin(1, 0, 2, 4)
+	filterStyle       = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("240"))
 )
 
 type item string
 
-func (i item) FilterValue() string { return "" }
+func (i item) FilterValue() string { return string(i) }
 
-type itemDelegate struct{}
+type itemDelegate struct {
+	// checked is nil for single selection. It is shared with the model so
+	// toggles made in Update are visible when rendering.
+	checked map[string]bool
+}
 
 func (d itemDelegate) Height() int                               { return 1 }
 func (d itemDelegate) Spacing() int                              { return 0 }
@@ -132,6 +125,13 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	}
 
 	str := fmt.Sprintf("%d. %s", index+1, i)
+	if d.checked != nil {
+		box := "[ ]"
+		if d.checked[string(i)] {
+			box = "[x]"
+		}
+		str = fmt.Sprintf("%s %s", box, i)
+	}
 
 	fn := itemStyle.Render
 	if index == m.Index() {
@@ -143,17 +143,62 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	fmt.Fprint(w, fn(str))
 }
 
+// fuzzyFilter returns the indexes of the targets matching term, best match first.
+// An empty term matches every target in its original order.
+func fuzzyFilter(term string, targets []string) []int {
+	var matches []int
+	if term == "" {
+		for i := range targets {
+			matches = append(matches, i)
+		}
+		return matches
+	}
+	for _, rank := range list.DefaultFilter(term, targets) {
+		matches = append(matches, rank.Index)
+	}
+	return matches
+}
+
+// updateQuery applies a key press to a type-to-filter query. It reports whether
+// the key was consumed by the query.
+func updateQuery(query *string, msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyRunes:
+		*query += string(msg.Runes)
+		return true
+	case tea.KeyBackspace:
+		if *query != "" {
+			r := []rune(*query)
+			*query = string(r[:len(r)-1])
+		}
+		return true
+	}
+	return false
+}
+
 type modelselect struct {
-	list         list.Model
-	choice       string
-	quitting     bool
-	clusteralias string
+	list     list.Model
+	options  []string
+	query    string
+	checked  map[string]bool
+	choice   string
+	choices  []string
+	quitting bool
 }
 
 func (m modelselect) Init() tea.Cmd {
 	return nil
 }
 
+func (m *modelselect) applyFilter() tea.Cmd {
+	items := []list.Item{}
+	for _, i := range fuzzyFilter(m.query, m.options) {
+		items = append(items, item(m.options[i]))
+	}
+	m.list.ResetSelected()
+	return m.list.SetItems(items)
+}
+
 func (m modelselect) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -166,13 +211,62 @@ func (m modelselect) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.quitting = true
 			return m, tea.Quit
 
+		case "esc":
+			if m.query != "" {
+				m.query = ""
+				return m, m.applyFilter()
+			}
+			m.quitting = true
+			return m, tea.Quit
+
+		case " ":
+			if m.checked != nil {
+				if i, ok := m.list.SelectedItem().(item); ok {
+					m.checked[string(i)] = !m.checked[string(i)]
+				}
+				return m, nil
+			}
+
+		case "ctrl+a":
+			if m.checked != nil {
+				visible := m.list.VisibleItems()
+				all := true
+				for _, v := range visible {
+					all = all && m.checked[string(v.(item))]
+				}
+				for _, v := range visible {
+					m.checked[string(v.(item))] = !all
+				}
+				return m, nil
+			}
+
 		case "enter":
 			i, ok := m.list.SelectedItem().(item)
+			if m.checked != nil {
+				for _, option := range m.options {
+					if m.checked[option] {
+						m.choices = append(m.choices, option)
+					}
+				}
+				if len(m.choices) == 0 && ok {
+					m.choices = []string{string(i)}
+				}
+				if len(m.choices) == 0 {
+					m.quitting = true
+				}
+				return m, tea.Quit
+			}
 			if ok {
 				m.choice = string(i)
+			} else {
+				m.quitting = true
 			}
 			return m, tea.Quit
 		}
+
+		if updateQuery(&m.query, msg) {
+			return m, m.applyFilter()
+		}
 	}
 
 	var cmd tea.Cmd
@@ -184,30 +278,73 @@ func (m modelselect) View() string {
 	if m.choice != "" {
 		return quitTextStyle.Render(fmt.Sprintf("Selected option is %s", m.choice))
 	}
+	if len(m.choices) > 0 {
+		return quitTextStyle.Render(fmt.Sprintf("Selected options are %s", strings.Join(m.choices, ", ")))
+	}
 	if m.quitting {
 		return quitTextStyle.Render("No option selected")
 	}
-	return "\n" + m.list.View()
+	filter := filterStyle.Render("Type to filter: " + m.query)
+	if len(m.list.VisibleItems()) == 0 {
+		filter += filterStyle.Render("(no matches)")
+	}
+	return "\n" + m.list.View() + "\n" + filter + "\n"
 }
 
-func PromptSelectInput(custtoken []string, msg string) string {
-
+func newSelectModel(options []string, msg string, multi bool) modelselect {
 	const defaultWidth = 20
 	items := []list.Item{}
 
-	for _, clusteralias := range custtoken {
-		items = append(items, item(clusteralias))
+	for _, option := range options {
+		items = append(items, item(option))
+	}
+
+	m := modelselect{options: options}
+	if multi {
+		m.checked = map[string]bool{}
 	}
 
-	l := list.New(items, itemDelegate{}, defaultWidth, listHeight)
+	l := list.New(items, itemDelegate{checked: m.checked}, defaultWidth, listHeight)
 	l.Title = msg
 	l.SetShowStatusBar(false)
+	// Filtering is handled by the model so that typing filters straight away
+	// instead of requiring '/' first.
 	l.SetFilteringEnabled(false)
+	l.KeyMap.Quit.SetEnabled(false)
 	l.Styles.Title = titleStyle
 	l.Styles.PaginationStyle = paginationStyle
 	l.Styles.HelpStyle = helpStyle
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		bindings := []key.Binding{
+			key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "select")),
+			key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "clear/quit")),
+		}
+		if multi {
+			bindings = append(bindings,
+				key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "toggle")),
+				key.NewBinding(key.WithKeys("ctrl+a"), key.WithHelp("ctrl+a", "toggle all")),
+			)
+		}
+		return bindings
+	}
+	// Letters are used for filtering, so only the non-printable keys move around.
+	l.KeyMap.CursorUp.SetKeys("up")
+	l.KeyMap.CursorDown.SetKeys("down")
+	l.KeyMap.PrevPage.SetKeys("left", "pgup")
+	l.KeyMap.NextPage.SetKeys("right", "pgdown")
+	l.KeyMap.GoToStart.SetKeys("home")
+	l.KeyMap.GoToEnd.SetKeys("end")
+	l.KeyMap.ShowFullHelp.SetEnabled(false)
+	l.KeyMap.CloseFullHelp.SetEnabled(false)
+
+	m.list = l
+	return m
+}
 
-	m := modelselect{list: l}
+// PromptSelectInput shows a list of options which can be filtered by typing and
+// returns the selected option, or an empty string if nothing was selected.
+func PromptSelectInput(custtoken []string, msg string) string {
+	m := newSelectModel(custtoken, msg, false)
 
 	x, err := tea.NewProgram(m).Run()
 	if err != nil {
@@ -221,235 +358,67 @@ func PromptSelectInput(custtoken []string, msg string) string {
 	return ""
 }
 
-var (
-	focusedStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("50"))
-	blurredStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("250"))
-	cursorStyle         = focusedStyle.Copy()
-	noStyle             = lipgloss.NewStyle()
-	helpStylePrompt     = blurredStyle.Copy()
-	cursorModeHelpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("50"))
-
-	focusedButton = focusedStyle.Copy().Render("[ Submit ]")
-	blurredButton = fmt.Sprintf("[ %s ]", blurredStyle.Render("Submit"))
-)
+// PromptMultiSelectInput works like PromptSelectInput but lets the user toggle
+// several options with space. When nothing is toggled the highlighted option is
+// returned, so it can be used as a drop-in replacement for single selection.
+func PromptMultiSelectInput(options []string, msg string) []string {
+	m := newSelectModel(options, msg, true)
 
-type promptmodel struct {
-	focusIndex int
-	inputs     []textinput.Model
-	cursorMode textinput.CursorMode
-	quitting   bool
-}
-
-func initialModel(promptfields *reflect.Value) promptmodel {
-	m := promptmodel{
-		inputs: make([]textinput.Model, promptfields.NumField()),
+	x, err := tea.NewProgram(m).Run()
+	if err != nil {
+		fmt.Println("Error running program:", err)
+		os.Exit(1)
 	}
-
-	var t textinput.Model
-	for i := range m.inputs {
-		t = textinput.New()
-		t.CursorStyle = cursorStyle
-		t.CharLimit = 32
-
-		fieldName := promptfields.Type().Field(i).Name
-		t.Prompt = "> " + fieldName + ": "
-		if i == 0 {
-			t.Focus()
-			t.PromptStyle = focusedStyle
-			t.TextStyle = focusedStyle
-		}
-		var Placeholder string
-		if promptfields.Field(i).Type().Name() == "int" {
-			Placeholder = fmt.Sprintf("%d", promptfields.Field(i).Int())
-		} else {
-			Placeholder = promptfields.Field(i).String()
-		}
-
-		t.Placeholder = " " + Placeholder
-
-		m.inputs[i] = t
-
+	if m, ok := x.(modelselect); ok && !m.quitting {
+		return m.choices
 	}
 
-	return m
-}
-
-func (m promptmodel) Init() tea.Cmd {
-	return textinput.Blink
-}
-
-func (m promptmodel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "esc":
-			m.quitting = true
-			return m, tea.Quit
-
-		// Change cursor mode
-		case "ctrl+r":
-			m.cursorMode++
-			if m.cursorMode > textinput.CursorHide {
-				m.cursorMode = textinput.CursorBlink
-			}
-			cmds := make([]tea.Cmd, len(m.inputs))
-			for i := range m.inputs {
-				cmds[i] = m.inputs[i].SetCursorMode(m.cursorMode)
-			}
-			return m, tea.Batch(cmds...)
-
-		// Set focus to next input
-		case "tab", "shift+tab", "enter", "up", "down":
-			s := msg.String()
-
-			// Did the user press enter while the submit button was focused?
-			// If so, exit.
-			if s == "enter" && m.focusIndex == len(m.inputs) {
-				return m, tea.Quit
-			}
-
-			// Cycle indexes
-			if s == "up" || s == "shift+tab" {
-				m.focusIndex--
-			} else {
-				m.focusIndex++
-			}
-
-			if m.focusIndex > len(m.inputs) {
-				m.focusIndex = 0
-			} else if m.focusIndex < 0 {
-				m.focusIndex = len(m.inputs)
-			}
-
-			cmds := make([]tea.Cmd, len(m.inputs))
-			for i := 0; i <= len(m.inputs)-1; i++ {
-				if i == m.focusIndex {
-					// Set focused state
-					cmds[i] = m.inputs[i].Focus()
-					m.inputs[i].PromptStyle = focusedStyle
-					m.inputs[i].TextStyle = focusedStyle
-					continue
-				}
-				// Remove focused state
-				m.inputs[i].Blur()
-				m.inputs[i].PromptStyle = noStyle
-				m.inputs[i].TextStyle = noStyle
-			}
-
-			return m, tea.Batch(cmds...)
-		}
-	}
-
-	// Handle character input and blinking
-	cmd := m.updateInputs(msg)
-
-	return m, cmd
+	return nil
 }
 
-func (m *promptmodel) updateInputs(msg tea.Msg) tea.Cmd {
-	cmds := make([]tea.Cmd, len(m.inputs))
-
-	// Only text inputs with Focus() set will respond, so it's safe to simply
-	// update all of them here without any further logic.
-	for i := range m.inputs {
-		m.inputs[i], cmds[i] = m.inputs[i].Update(msg)
-	}
-
-	return tea.Batch(cmds...)
+// headless reports whether there's no display to open a graphical browser on.
+func headless() bool {
+	return runtime.GOOS == "linux" && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == ""
 }
 
-func (m promptmodel) View() string {
-	var b strings.Builder
-
-	for i := range m.inputs {
-		b.WriteString(m.inputs[i].View())
-		if i < len(m.inputs)-1 {
-			b.WriteRune('\n')
-		}
-	}
-
-	button := &blurredButton
-	if m.focusIndex == len(m.inputs) {
-		button = &focusedButton
+// BrowserCommand returns the configured browser command, if any.
+func BrowserCommand() string {
+	if command := viper.GetString("browser"); command != "" {
+		return command
 	}
-	fmt.Fprintf(&b, "\n\n%s\n\n", *button)
-
-	b.WriteString(helpStylePrompt.Render("cursor mode is "))
-	b.WriteString(cursorModeHelpStyle.Render(m.cursorMode.String()))
-	b.WriteString(helpStylePrompt.Render(" (ctrl+r to change style)"))
-
-	return b.String()
+	return os.Getenv("BROWSER")
 }
 
-func PromptTextInput(promptvalue *reflect.Value) error {
-
-	x, err := tea.NewProgram(initialModel(promptvalue)).Run()
-	if err != nil {
-		fmt.Printf("could not start program: %s\n", err)
-		os.Exit(1)
-	}
-
-	if x.(promptmodel).quitting == true {
-		return fmt.Errorf(":Prompt Exit")
-	}
-
-	m, _ := x.(promptmodel)
-	{
-		for i, promptinput := range m.inputs {
-			field := promptvalue.Field(i)
+/*
+Openbrowser opens url in a browser. The browser command comes from the 'browser' setting, $BROWSER, or else the
+// default browser of the platform. The url replaces %s in the command, or is appended to it.
+*/
+func Openbrowser(url string) error {
+	var err error
 
-			if promptinput.Value() == "" {
-				validateinput(&field, field.String())
-			} else {
-				validateinput(&field, promptinput.Value())
+	if command := BrowserCommand(); command != "" {
+		args := strings.Fields(command)
+		if strings.Contains(command, "%s") {
+			for i := range args {
+				args[i] = strings.ReplaceAll(args[i], "%s", url)
 			}
-
-		}
-
-	}
-
-	return nil
-}
-
-func validateinput(field *reflect.Value, promptValue string) error {
-	fieldKind := field.Kind()
-
-	switch fieldKind {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		d, err := strconv.ParseInt(promptValue, 0, 64)
-		if err != nil {
-			return fmt.Errorf("string to int64 conversion error %q", err.Error())
-		}
-		field.SetInt(reflect.ValueOf(d).Int())
-
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		d, err := strconv.ParseUint(promptValue, 0, 64)
-		if err != nil {
-			return fmt.Errorf("string to uint64 conversion error %q", err.Error())
+		} else {
+			args = append(args, url)
 		}
-		field.SetUint(reflect.ValueOf(d).Uint())
-
-	case reflect.Float32, reflect.Float64:
-		d, err := strconv.ParseFloat(promptValue, 64)
-		if err != nil {
-			return fmt.Errorf("string to float64 conversion error %q", err.Error())
+		browser := exec.Command(args[0], args[1:]...)
+		if !headless() {
+			return browser.Start()
 		}
-		field.SetFloat(reflect.ValueOf(d).Float())
-
-	case reflect.String:
-		field.Set(reflect.ValueOf(promptValue))
-
-	default:
-		return fmt.Errorf("unsupported field type provided %q. supports int, int64, uint64, float64, string", fieldKind)
+		// Without a display it's a terminal browser, which gets the terminal until it exits.
+		browser.Stdin, browser.Stdout, browser.Stderr = os.Stdin, os.Stdout, os.Stderr
+		return browser.Run()
 	}
-	return nil
-}
-
-func Openbrowser(url string) error {
-	var err error
 
 	switch runtime.GOOS {
 	case "linux":
+		if headless() {
+			return fmt.Errorf("no display is available, set the 'browser' setting or $BROWSER to use a terminal browser")
+		}
 		err = exec.Command("xdg-open", url).Start()
 	case "windows":
 		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
@@ -466,29 +435,64 @@ var baseStyle = lipgloss.NewStyle().
 	BorderStyle(lipgloss.NormalBorder()).
 	BorderForeground(lipgloss.Color("240"))
 
+// tableMaxHeight is the number of rows TableInput shows before scrolling.
+const tableMaxHeight = 15
+
 type modeltable struct {
-	table table.Model
-	choice table.Row
+	table   table.Model
+	rows    []table.Row
+	query   string
+	choice  table.Row
+	maxRows int
 }
 
 func (m modeltable) Init() tea.Cmd { return nil }
 
+func (m *modeltable) applyFilter() {
+	targets := make([]string, len(m.rows))
+	for i, row := range m.rows {
+		targets[i] = strings.Join(row, " ")
+	}
+	var rows []table.Row
+	for _, i := range fuzzyFilter(m.query, targets) {
+		rows = append(rows, m.rows[i])
+	}
+	m.table.SetRows(rows)
+	m.table.SetCursor(0)
+}
+
 func (m modeltable) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Leave room for the header, borders and the filter line.
+		if h := msg.Height - 6; h > 0 && h < m.maxRows {
+			m.table.SetHeight(h)
+		} else {
+			m.table.SetHeight(m.maxRows)
+		}
+		return m, nil
+
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
-			if m.table.Focused() {
-				m.table.Blur()
-			} else {
-				m.table.Focus()
+			if m.query != "" {
+				m.query = ""
+				m.applyFilter()
+				return m, nil
 			}
-		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "ctrl+c":
 			return m, tea.Quit
 		case "enter":
-			m.choice=m.table.SelectedRow()
-			return m,tea.Quit
+			if len(m.table.Rows()) > 0 {
+				m.choice = m.table.SelectedRow()
+			}
+			return m, tea.Quit
+		}
+		if updateQuery(&m.query, msg) {
+			m.applyFilter()
+			return m, nil
 		}
 	}
 	m.table, cmd = m.table.Update(msg)
@@ -496,19 +500,42 @@ func (m modeltable) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m modeltable) View() string {
-	return baseStyle.Render(m.table.View()) + "\n"
+	filter := filterStyle.Render("Type to filter: " + m.query)
+	if len(m.table.Rows()) == 0 {
+		filter += filterStyle.Render("(no matches)")
+	}
+	return baseStyle.Render(m.table.View()) + "\n" + filter + "\n" +
+		helpStylePrompt.Render("  ↑/↓ move • enter select • esc clear/quit") + "\n"
 }
 
-func TableInput(columninput []table.Column,rowinput[]table.Row)table.Row{
-	columns := columninput
+// TableInput shows the rows in a table which can be filtered by typing and
+// returns the selected row, or an empty row if nothing was selected.
+func TableInput(columninput []table.Column, rowinput []table.Row) table.Row {
+	height := len(rowinput)
+	if height > tableMaxHeight {
+		height = tableMaxHeight
+	}
+	if height < 1 {
+		height = 1
+	}
 
-	rows := rowinput
+	// Letters are used for filtering, so only the non-printable keys move around.
+	keys := table.DefaultKeyMap()
+	keys.LineUp.SetKeys("up")
+	keys.LineDown.SetKeys("down")
+	keys.PageUp.SetKeys("pgup")
+	keys.PageDown.SetKeys("pgdown")
+	keys.HalfPageUp.SetKeys("ctrl+u")
+	keys.HalfPageDown.SetKeys("ctrl+d")
+	keys.GotoTop.SetKeys("home")
+	keys.GotoBottom.SetKeys("end")
 
 	t := table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
+		table.WithColumns(columninput),
+		table.WithRows(rowinput),
 		table.WithFocused(true),
-		table.WithHeight(7),
+		table.WithHeight(height),
+		table.WithKeyMap(keys),
 	)
 
 	s := table.DefaultStyles()
@@ -523,17 +550,16 @@ func TableInput(columninput []table.Column,rowinput[]table.Row)table.Row{
 		Bold(true)
 	t.SetStyles(s)
 
-	m := modeltable{t,table.Row{}}
-	x, err := tea.NewProgram(m).Run(); 
+	m := modeltable{table: t, rows: rowinput, maxRows: height}
+	x, err := tea.NewProgram(m).Run()
 	if err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
 
 	if m, ok := x.(modeltable); ok {
-	return m.choice
+		return m.choice
 	}
 
-
 	return table.Row{}
-}
\ No newline at end of file
+}